@@ -55,6 +55,37 @@ func MakeTransformerConfig(
 	return t1.Merge(t2)
 }
 
+// DeepCopy returns a copy of the TransformerConfig
+// sharing no slices with it, so that adding field
+// specs to one doesn't change the other.
+func (t *TransformerConfig) DeepCopy() *TransformerConfig {
+	if t == nil {
+		return nil
+	}
+	nr := make(nbrSlice, len(t.NameReference))
+	for i, n := range t.NameReference {
+		nr[i] = NameBackReferences{
+			Gvk:        n.Gvk,
+			FieldSpecs: copyFsSlice(n.FieldSpecs),
+		}
+	}
+	return &TransformerConfig{
+		NamePrefix:        copyFsSlice(t.NamePrefix),
+		NameSuffix:        copyFsSlice(t.NameSuffix),
+		NameSpace:         copyFsSlice(t.NameSpace),
+		CommonLabels:      copyFsSlice(t.CommonLabels),
+		CommonAnnotations: copyFsSlice(t.CommonAnnotations),
+		NameReference:     nr,
+		VarReference:      copyFsSlice(t.VarReference),
+		Images:            copyFsSlice(t.Images),
+		Replicas:          copyFsSlice(t.Replicas),
+	}
+}
+
+func copyFsSlice(s types.FsSlice) types.FsSlice {
+	return append(types.FsSlice(nil), s...)
+}
+
 // sortFields provides determinism in logging, tests, etc.
 func (t *TransformerConfig) sortFields() {
 	sort.Sort(t.NamePrefix)
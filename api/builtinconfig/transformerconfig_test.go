@@ -44,6 +44,38 @@ func TestAddNamereferenceFieldSpec(t *testing.T) {
 	}
 }
 
+func TestDeepCopy(t *testing.T) {
+	cfg := MakeDefaultConfig()
+	c := cfg.DeepCopy()
+	if !reflect.DeepEqual(c, cfg) {
+		t.Fatalf("expected an equal copy")
+	}
+	fs := types.FieldSpec{
+		Gvk:  resid.Gvk{Kind: "KindA"},
+		Path: "path/to/a/field",
+	}
+	for _, add := range []func(*TransformerConfig) error{
+		func(tc *TransformerConfig) error { return tc.AddLabelFieldSpec(fs) },
+		func(tc *TransformerConfig) error {
+			return tc.AddNamereferenceFieldSpec(NameBackReferences{
+				Gvk:        resid.Gvk{Kind: "ConfigMap", Version: "v1"},
+				FieldSpecs: []types.FieldSpec{fs},
+			})
+		},
+	} {
+		err := add(c)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+	if reflect.DeepEqual(c, cfg) {
+		t.Fatalf("expected the copy to change")
+	}
+	if !reflect.DeepEqual(cfg, MakeDefaultConfig()) {
+		t.Fatalf("expected the original not to change")
+	}
+}
+
 func TestAddFieldSpecs(t *testing.T) {
 	cfg := &TransformerConfig{}
 
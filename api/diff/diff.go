@@ -0,0 +1,156 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+)
+
+// DefaultIgnoredFields are fields populated by the
+// server, which don't appear in a build, so that
+// comparing a build to resources exported from a
+// cluster isn't swamped by them.
+var DefaultIgnoredFields = []string{
+	"status",
+	"metadata/uid",
+	"metadata/resourceVersion",
+	"metadata/generation",
+	"metadata/creationTimestamp",
+	"metadata/selfLink",
+	"metadata/managedFields",
+	"metadata/annotations/kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// ResourceDiff is the difference between a resource
+// on the left and its counterpart on the right.
+// If Left is empty, the resource is only on the right;
+// if Right is empty, the resource is only on the left.
+type ResourceDiff struct {
+	Left   string        `json:"left,omitempty"`
+	Right  string        `json:"right,omitempty"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Diff is the set of differences between two ResMaps.
+type Diff []ResourceDiff
+
+// ResMaps returns the differences between the left
+// and right ResMaps, ignoring the given fields.
+//
+// Resources are paired by their current id.  The
+// resources left over are then paired by their
+// original id, which pairs resources that differ
+// only by name prefix, suffix or namespace.  As
+// with ResId.Equals, no namespace and the default
+// namespace are the same.  Pairs that don't differ
+// are omitted.
+func ResMaps(left, right resmap.ResMap, ignore []string) Diff {
+	var result Diff
+	unpairedLeft, unpairedRight := pair(
+		left.Resources(), right.Resources(),
+		func(r *resource.Resource) string { return idKey(r.CurId()) },
+		ignore, &result)
+	unpairedLeft, unpairedRight = pair(
+		unpairedLeft, unpairedRight,
+		func(r *resource.Resource) string { return idKey(r.OrgId()) },
+		ignore, &result)
+	for _, r := range unpairedLeft {
+		result = append(result, ResourceDiff{Left: r.CurId().String()})
+	}
+	for _, r := range unpairedRight {
+		result = append(result, ResourceDiff{Right: r.CurId().String()})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].id() < result[j].id()
+	})
+	return result
+}
+
+// idKey returns a key pairing resources
+// whose ids are equal per ResId.Equals.
+func idKey(id resid.ResId) string {
+	id.Namespace = id.EffectiveNamespace()
+	return id.String()
+}
+
+// pair pairs resources by the given key, appending
+// the differences between pairs to result, and
+// returning the resources that weren't paired.
+func pair(
+	left, right []*resource.Resource,
+	key func(*resource.Resource) string,
+	ignore []string, result *Diff) (
+	unpairedLeft, unpairedRight []*resource.Resource) {
+	byKey := make(map[string][]*resource.Resource)
+	for _, r := range right {
+		k := key(r)
+		byKey[k] = append(byKey[k], r)
+	}
+	paired := make(map[*resource.Resource]bool)
+	for _, l := range left {
+		candidates := byKey[key(l)]
+		if len(candidates) == 0 {
+			unpairedLeft = append(unpairedLeft, l)
+			continue
+		}
+		r := candidates[0]
+		byKey[key(l)] = candidates[1:]
+		paired[r] = true
+		ignored := ignore
+		if l.GetNamespace() != r.GetNamespace() &&
+			l.CurId().IsNsEquals(r.CurId()) {
+			// One has no namespace, the other the default.
+			ignored = append(
+				append([]string(nil), ignore...), "metadata/namespace")
+		}
+		fields := Fields(l.Map(), r.Map(), ignored)
+		if len(fields) > 0 {
+			*result = append(*result, ResourceDiff{
+				Left:   l.CurId().String(),
+				Right:  r.CurId().String(),
+				Fields: fields,
+			})
+		}
+	}
+	for _, r := range right {
+		if !paired[r] {
+			unpairedRight = append(unpairedRight, r)
+		}
+	}
+	return
+}
+
+func (d ResourceDiff) id() string {
+	if d.Left != "" {
+		return d.Left
+	}
+	return d.Right
+}
+
+// Report returns the differences as a human-readable report.
+func (d Diff) Report() []byte {
+	var b bytes.Buffer
+	for _, r := range d {
+		switch {
+		case r.Right == "":
+			fmt.Fprintf(&b, "only in left: %s\n", r.Left)
+		case r.Left == "":
+			fmt.Fprintf(&b, "only in right: %s\n", r.Right)
+		case r.Left == r.Right:
+			fmt.Fprintf(&b, "changed %s\n", r.Left)
+		default:
+			fmt.Fprintf(&b, "changed %s (right: %s)\n", r.Left, r.Right)
+		}
+		for _, f := range r.Fields {
+			fmt.Fprintf(&b, "  %s\n", f)
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "sigs.k8s.io/kustomize/v3/api/diff"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+)
+
+var rmF = resmap.NewFactory(resource.NewFactory(
+	kunstruct.NewKunstructuredFactoryImpl()), nil)
+
+func makeResMap(t *testing.T, y string) resmap.ResMap {
+	m, err := rmF.NewResMapFromBytes([]byte(y))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestResMaps(t *testing.T) {
+	left := makeResMap(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: same
+data:
+  a: b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+data:
+  a: b
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: gone
+`)
+	right := makeResMap(t, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: new
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+  resourceVersion: "42"
+data:
+  a: c
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: same
+  uid: "1234"
+data:
+  a: b
+status:
+  phase: whatever
+`)
+	expected := `changed ~G_v1_ConfigMap|~X|changed
+  data/a: "b" -> "c"
+only in left: ~G_v1_Service|~X|gone
+only in right: ~G_v1_Service|~X|new
+`
+	d := ResMaps(left, right, DefaultIgnoredFields)
+	if string(d.Report()) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, d.Report())
+	}
+}
+
+func TestResMapsPairsByOriginalId(t *testing.T) {
+	left := makeResMap(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: b
+`)
+	right := makeResMap(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: b
+`)
+	r := right.GetByIndex(0)
+	r.SetName("p-cm")
+	r.SetNamespace("ns")
+	expected := Diff{
+		{
+			Left:  "~G_v1_ConfigMap|~X|cm",
+			Right: "~G_v1_ConfigMap|ns|p-cm",
+			Fields: []FieldChange{
+				{Path: "metadata/name", Old: "cm", New: "p-cm"},
+				{Path: "metadata/namespace", New: "ns"},
+			},
+		},
+	}
+	d := ResMaps(left, right, nil)
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expected, d)
+	}
+}
+
+func TestResMapsPairsDefaultNamespace(t *testing.T) {
+	left := makeResMap(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  a: b
+`)
+	right := makeResMap(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: default
+data:
+  a: c
+`)
+	expected := Diff{
+		{
+			Left:  "~G_v1_ConfigMap|~X|cm",
+			Right: "~G_v1_ConfigMap|default|cm",
+			Fields: []FieldChange{
+				{Path: "data/a", Old: "b", New: "c"},
+			},
+		},
+	}
+	d := ResMaps(left, right, nil)
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expected, d)
+	}
+}
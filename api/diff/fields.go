@@ -0,0 +1,129 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package diff finds field level differences
+// between resources.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldChange is a difference in one field of a resource.
+// The Path uses '/' to separate map keys, with list
+// indices in brackets, e.g. spec/containers[0]/image.
+// A nil Old value means the field was added; a nil New
+// value means the field was removed.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// String returns the change in the form
+// used in reports, e.g. 'metadata/name: "a" -> "b"'.
+func (c FieldChange) String() string {
+	return c.Path + ": " + formatValue(c.Old) + " -> " + formatValue(c.New)
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(out)
+}
+
+// Fields returns the differences between two objects,
+// sorted by path.  Differences at or below any of the
+// ignored paths are dropped.
+func Fields(old, now map[string]interface{}, ignore []string) []FieldChange {
+	return compare(flatten(old, ignore), flatten(now, ignore))
+}
+
+// flatten returns the leaves of the given object,
+// keyed by their path.  Empty maps and lists are
+// leaves too, so that their appearance is noticed.
+func flatten(
+	obj map[string]interface{}, ignore []string) map[string]interface{} {
+	result := make(map[string]interface{})
+	if obj != nil {
+		flattenInto(result, "", obj, ignore)
+	}
+	return result
+}
+
+func flattenInto(
+	result map[string]interface{}, path string,
+	v interface{}, ignore []string) {
+	if isIgnored(path, ignore) {
+		return
+	}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if len(x) == 0 && path != "" {
+			result[path] = x
+		}
+		for k, e := range x {
+			p := k
+			if path != "" {
+				p = path + "/" + k
+			}
+			flattenInto(result, p, e, ignore)
+		}
+	case []interface{}:
+		if len(x) == 0 {
+			result[path] = x
+		}
+		for i, e := range x {
+			flattenInto(result, fmt.Sprintf("%s[%d]", path, i), e, ignore)
+		}
+	default:
+		result[path] = v
+	}
+}
+
+// isIgnored returns true if the path is one of the
+// ignored paths, or is below one of them.
+func isIgnored(path string, ignore []string) bool {
+	for _, i := range ignore {
+		if path == i ||
+			strings.HasPrefix(path, i+"/") ||
+			strings.HasPrefix(path, i+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// compare returns the differences between two
+// flattened objects, sorted by path.
+func compare(old, now map[string]interface{}) []FieldChange {
+	var result []FieldChange
+	for p, o := range old {
+		n, ok := now[p]
+		if !ok {
+			result = append(result, FieldChange{Path: p, Old: o})
+			continue
+		}
+		if !reflect.DeepEqual(o, n) {
+			result = append(result, FieldChange{Path: p, Old: o, New: n})
+		}
+	}
+	for p, n := range now {
+		if _, ok := old[p]; !ok {
+			result = append(result, FieldChange{Path: p, New: n})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
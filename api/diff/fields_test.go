@@ -0,0 +1,50 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "sigs.k8s.io/kustomize/v3/api/diff"
+)
+
+func TestFields(t *testing.T) {
+	old := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "a",
+			"uid":  "1234",
+		},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": 80},
+			},
+		},
+		"status": map[string]interface{}{"ready": true},
+	}
+	now := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "b",
+			"labels": map[string]interface{}{},
+		},
+		"spec": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"port": 80},
+				map[string]interface{}{"port": 443},
+			},
+		},
+	}
+	expected := []FieldChange{
+		{Path: "metadata/labels", New: map[string]interface{}{}},
+		{Path: "metadata/name", Old: "a", New: "b"},
+		{Path: "spec/ports[1]/port", New: 443},
+	}
+	actual := Fields(old, now, []string{"status", "metadata/uid"})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expected, actual)
+	}
+	if s := actual[1].String(); s != `metadata/name: "a" -> "b"` {
+		t.Fatalf("unexpected string %s", s)
+	}
+}
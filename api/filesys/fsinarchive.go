@@ -0,0 +1,381 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filesys
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var _ FileSystem = &fsInArchive{}
+
+// MaxArchiveSize is the most bytes MakeFsInArchive unpacks
+// from an archive, counting all the files in it, or for a
+// .tar.gz, the whole tar.  It keeps a small archive that
+// unpacks to far more from exhausting memory.
+const MaxArchiveSize = 64 << 20
+
+// archiveBudget is the count of bytes left
+// to unpack from an archive.
+type archiveBudget int64
+
+// readAll reads all of r, failing if that's more
+// than the bytes left to unpack.
+func (b *archiveBudget) readAll(r io.Reader) ([]byte, error) {
+	c, err := ioutil.ReadAll(io.LimitReader(r, int64(*b)+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(c)) > int64(*b) {
+		return nil, fmt.Errorf(
+			"archive unpacks to more than %d bytes", MaxArchiveSize)
+	}
+	*b -= archiveBudget(len(c))
+	return c, nil
+}
+
+// archiveExtensions maps the file name extensions
+// of supported archives to functions reading them.
+var archiveExtensions = []struct {
+	ext  string
+	read func([]byte, func(string, []byte)) error
+}{
+	{".tar.gz", readTarGz},
+	{".tgz", readTarGz},
+	{".tar", readTar},
+	{".zip", readZip},
+}
+
+// IsArchiveName returns true if the name has the
+// extension of an archive that MakeFsInArchive reads,
+// i.e. .tar.gz, .tgz, .tar or .zip.
+func IsArchiveName(name string) bool {
+	return archiveReader(name) != nil
+}
+
+func archiveReader(name string) func([]byte, func(string, []byte)) error {
+	lower := strings.ToLower(name)
+	for _, a := range archiveExtensions {
+		if strings.HasSuffix(lower, a.ext) {
+			return a.read
+		}
+	}
+	return nil
+}
+
+// fsInArchive implements a read-only FileSystem holding
+// the regular files of an archive, as if the archive were
+// a directory.  Anything else in the archive, e.g. symlinks,
+// is left out.
+type fsInArchive struct {
+	mount ConfirmedDir
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// MakeFsInArchive returns a FileSystem holding the
+// content of the named archive, which appears as a
+// directory at the absolute path mount.  The name's
+// extension determines the archive's format.
+func MakeFsInArchive(
+	mount ConfirmedDir, name string, content []byte) (FileSystem, error) {
+	read := archiveReader(name)
+	if read == nil {
+		return nil, fmt.Errorf("'%s' is not a .tar.gz, .tgz, .tar or .zip", name)
+	}
+	fs := &fsInArchive{
+		mount: mount,
+		files: map[string][]byte{},
+		dirs:  map[string]bool{mount.String(): true},
+	}
+	err := read(content, fs.add)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive '%s': %v", name, err)
+	}
+	return fs, nil
+}
+
+// add adds a file, and the directories holding it,
+// taking care that no name escapes the mount.
+func (fs *fsInArchive) add(name string, content []byte) {
+	path := fs.mount.Join(filepath.Clean(separator + filepath.FromSlash(name)))
+	if path == fs.mount.String() {
+		return
+	}
+	fs.files[path] = content
+	for d := filepath.Dir(path); !fs.dirs[d]; d = filepath.Dir(d) {
+		fs.dirs[d] = true
+	}
+}
+
+func readTarGz(content []byte, add func(string, []byte)) error {
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	budget := archiveBudget(MaxArchiveSize)
+	content, err = budget.readAll(r)
+	if err != nil {
+		return err
+	}
+	return readTar(content, add)
+}
+
+func readTar(content []byte, add func(string, []byte)) error {
+	r := tar.NewReader(bytes.NewReader(content))
+	budget := archiveBudget(MaxArchiveSize)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+			continue
+		}
+		c, err := budget.readAll(r)
+		if err != nil {
+			return err
+		}
+		add(h.Name, c)
+	}
+}
+
+func readZip(content []byte, add func(string, []byte)) error {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+	budget := archiveBudget(MaxArchiveSize)
+	for _, f := range r.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		c, err := budget.readAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		add(f.Name, c)
+	}
+	return nil
+}
+
+func (fs *fsInArchive) errReadOnly(path string) error {
+	return fmt.Errorf(
+		"cannot write '%s'; archive '%s' is read-only", path, fs.mount)
+}
+
+// Create fails; the archive is read-only.
+func (fs *fsInArchive) Create(name string) (File, error) {
+	return nil, fs.errReadOnly(name)
+}
+
+// Mkdir fails; the archive is read-only.
+func (fs *fsInArchive) Mkdir(name string) error {
+	return fs.errReadOnly(name)
+}
+
+// MkdirAll fails; the archive is read-only.
+func (fs *fsInArchive) MkdirAll(name string) error {
+	return fs.errReadOnly(name)
+}
+
+// RemoveAll fails; the archive is read-only.
+func (fs *fsInArchive) RemoveAll(name string) error {
+	return fs.errReadOnly(name)
+}
+
+// Open returns the file for reading.
+func (fs *fsInArchive) Open(name string) (File, error) {
+	content, found := fs.files[filepath.Clean(name)]
+	if !found {
+		return nil, fmt.Errorf("file %q cannot be opened", name)
+	}
+	return &fileInArchive{
+		Reader: bytes.NewReader(content),
+		info:   archiveFileInfo{name: filepath.Base(name), size: len(content)},
+	}, nil
+}
+
+// CleanedAbs cleans the path, which must be in the archive.
+// There are no symlinks to resolve.
+func (fs *fsInArchive) CleanedAbs(path string) (ConfirmedDir, string, error) {
+	path = filepath.Clean(path)
+	if fs.dirs[path] {
+		return ConfirmedDir(path), "", nil
+	}
+	if _, found := fs.files[path]; !found {
+		return "", "", fmt.Errorf(
+			"'%s' is not in archive '%s'", path, fs.mount)
+	}
+	return ConfirmedDir(filepath.Dir(path)), filepath.Base(path), nil
+}
+
+// Exists returns true if the file or directory is in the archive.
+func (fs *fsInArchive) Exists(name string) bool {
+	name = filepath.Clean(name)
+	_, found := fs.files[name]
+	return found || fs.dirs[name]
+}
+
+// Glob returns the list of matching files.
+func (fs *fsInArchive) Glob(pattern string) ([]string, error) {
+	var result []string
+	for _, paths := range []map[string]bool{fs.dirs, fs.fileSet()} {
+		for p := range paths {
+			match, err := filepath.Match(pattern, p)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				result = append(result, p)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (fs *fsInArchive) fileSet() map[string]bool {
+	result := make(map[string]bool, len(fs.files))
+	for p := range fs.files {
+		result[p] = true
+	}
+	return result
+}
+
+// IsDir returns true if the path is a directory in the archive.
+func (fs *fsInArchive) IsDir(name string) bool {
+	return fs.dirs[filepath.Clean(name)]
+}
+
+// ReadFile returns the content of the file in the archive.
+func (fs *fsInArchive) ReadFile(name string) ([]byte, error) {
+	if content, found := fs.files[filepath.Clean(name)]; found {
+		return content, nil
+	}
+	return nil, fmt.Errorf("cannot read file %q", name)
+}
+
+// WriteFile fails; the archive is read-only.
+func (fs *fsInArchive) WriteFile(name string, _ []byte) error {
+	return fs.errReadOnly(name)
+}
+
+// Walk implements filepath.Walk over the archive,
+// visiting entries in lexical order.
+func (fs *fsInArchive) Walk(path string, walkFn filepath.WalkFunc) error {
+	path = filepath.Clean(path)
+	info, err := fs.lstat(path)
+	if err != nil {
+		err = walkFn(path, nil, err)
+	} else {
+		err = fs.walk(path, info, walkFn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func (fs *fsInArchive) lstat(path string) (os.FileInfo, error) {
+	if fs.dirs[path] {
+		return archiveFileInfo{name: filepath.Base(path), dir: true}, nil
+	}
+	if content, found := fs.files[path]; found {
+		return archiveFileInfo{name: filepath.Base(path), size: len(content)}, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (fs *fsInArchive) walk(
+	path string, info os.FileInfo, walkFn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+	if err := walkFn(path, info, nil); err != nil {
+		return err
+	}
+	for _, name := range fs.readDirNames(path) {
+		child := filepath.Join(path, name)
+		childInfo, _ := fs.lstat(child)
+		err := fs.walk(child, childInfo, walkFn)
+		if err != nil && (!childInfo.IsDir() || err != filepath.SkipDir) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *fsInArchive) readDirNames(path string) []string {
+	var names []string
+	for _, paths := range []map[string]bool{fs.dirs, fs.fileSet()} {
+		for p := range paths {
+			if p != path && filepath.Dir(p) == path {
+				names = append(names, filepath.Base(p))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ File = &fileInArchive{}
+
+// fileInArchive is a file opened for reading.
+type fileInArchive struct {
+	*bytes.Reader
+	info archiveFileInfo
+}
+
+func (f *fileInArchive) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("cannot write '%s'; archives are read-only", f.info.name)
+}
+
+func (f *fileInArchive) Close() error { return nil }
+
+func (f *fileInArchive) Stat() (os.FileInfo, error) { return f.info, nil }
+
+var _ os.FileInfo = archiveFileInfo{}
+
+type archiveFileInfo struct {
+	name string
+	size int
+	dir  bool
+}
+
+func (fi archiveFileInfo) Name() string { return fi.name }
+
+func (fi archiveFileInfo) Size() int64 { return int64(fi.size) }
+
+func (fi archiveFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (fi archiveFileInfo) ModTime() time.Time { return time.Time{} }
+
+func (fi archiveFileInfo) IsDir() bool { return fi.dir }
+
+func (fi archiveFileInfo) Sys() interface{} { return nil }
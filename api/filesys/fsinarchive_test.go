@@ -0,0 +1,188 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filesys_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "sigs.k8s.io/kustomize/v3/api/filesys"
+)
+
+var archiveFiles = map[string]string{
+	"platform/base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+	"platform/base/deployment.yaml":    "kind: Deployment\n",
+	"../../escape.yaml":                "kind: Escapee\n",
+}
+
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0644, Size: int64(len(content)),
+			Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	// A symlink, which is left out.
+	tw.WriteHeader(&tar.Header{
+		Name: "platform/passwd", Linkname: "/etc/passwd",
+		Typeflag: tar.TypeSymlink})
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestIsArchiveName(t *testing.T) {
+	testCases := map[string]bool{
+		"platform-1.4.tar.gz": true,
+		"platform-1.4.TGZ":    true,
+		"platform.tar":        true,
+		"bases/platform.zip":  true,
+		"platform.gz":         false,
+		"platform":            false,
+	}
+	for name, expected := range testCases {
+		if IsArchiveName(name) != expected {
+			t.Errorf("%s: expected %v", name, expected)
+		}
+	}
+}
+
+func TestMakeFsInArchive(t *testing.T) {
+	for name, content := range map[string][]byte{
+		"platform.tgz": makeTarGz(t, archiveFiles),
+		"platform.zip": makeZip(t, archiveFiles),
+	} {
+		mount := ConfirmedDir("/app/bases/" + name)
+		fSys, err := MakeFsInArchive(mount, name, content)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for _, dir := range []string{
+			"/app/bases/" + name,
+			"/app/bases/" + name + "/platform",
+			"/app/bases/" + name + "/platform/base/",
+		} {
+			if !fSys.IsDir(dir) {
+				t.Fatalf("%s: expected dir %s", name, dir)
+			}
+		}
+		if fSys.IsDir("/app/bases") {
+			t.Fatalf("%s: expected the archive alone", name)
+		}
+		c, err := fSys.ReadFile(mount.Join("platform/base/deployment.yaml"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if string(c) != "kind: Deployment\n" {
+			t.Fatalf("%s: unexpected content %s", name, c)
+		}
+		if !fSys.Exists(mount.Join("escape.yaml")) {
+			t.Fatalf("%s: expected escape.yaml to stay in the archive", name)
+		}
+		if fSys.Exists(mount.Join("platform/passwd")) {
+			t.Fatalf("%s: expected no symlink", name)
+		}
+
+		d, f, err := fSys.CleanedAbs(
+			mount.Join("platform/base/../base/kustomization.yaml"))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if d != ConfirmedDir(mount.Join("platform/base")) ||
+			f != "kustomization.yaml" {
+			t.Fatalf("%s: unexpected %s, %s", name, d, f)
+		}
+		_, _, err = fSys.CleanedAbs("/app/other.yaml")
+		if err == nil || !strings.Contains(err.Error(), "is not in archive") {
+			t.Fatalf("%s: expected error, got %v", name, err)
+		}
+
+		var walked []string
+		err = fSys.Walk(mount.String(),
+			func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.Name() != filepath.Base(path) {
+					t.Fatalf("%s: unexpected name %s", path, info.Name())
+				}
+				walked = append(walked, path)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		expected := []string{
+			mount.String(),
+			mount.Join("escape.yaml"),
+			mount.Join("platform"),
+			mount.Join("platform/base"),
+			mount.Join("platform/base/deployment.yaml"),
+			mount.Join("platform/base/kustomization.yaml"),
+		}
+		if !reflect.DeepEqual(walked, expected) {
+			t.Fatalf("%s: expected %v, got %v", name, expected, walked)
+		}
+
+		err = fSys.WriteFile(mount.Join("new.yaml"), []byte("{}"))
+		if err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Fatalf("%s: expected read-only error, got %v", name, err)
+		}
+	}
+}
+
+func TestMakeFsInArchiveTooBig(t *testing.T) {
+	half := strings.Repeat("\x00", MaxArchiveSize/2+1)
+	for name, content := range map[string][]byte{
+		"platform.tgz": makeTarGz(t, map[string]string{
+			"a": half, "b": half}),
+		"platform.zip": makeZip(t, map[string]string{
+			"a": half, "b": half}),
+	} {
+		_, err := MakeFsInArchive(ConfirmedDir("/app/"+name), name, content)
+		if err == nil || !strings.Contains(err.Error(), "unpacks to more than") {
+			t.Fatalf("%s: expected error, got %v", name, err)
+		}
+	}
+}
+
+func TestMakeFsInArchiveErrors(t *testing.T) {
+	_, err := MakeFsInArchive("/app/platform.gz", "platform.gz", nil)
+	if err == nil || !strings.Contains(err.Error(), "is not a .tar.gz") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	_, err = MakeFsInArchive(
+		"/app/platform.tgz", "platform.tgz", []byte("not gzip"))
+	if err == nil ||
+		!strings.Contains(err.Error(), "reading archive 'platform.tgz'") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
@@ -34,6 +34,20 @@ type Loader interface {
 	New(newRoot string) (Loader, error)
 	// Load returns the bytes read from the location or an error.
 	Load(location string) ([]byte, error)
+	// LoadRemoteFile returns the bytes fetched from the url
+	// of a remote file, e.g. a resources or patches entry
+	// naming one, or an error.  Load never fetches urls.
+	LoadRemoteFile(url string) ([]byte, error)
+	// Repo returns the clone spec and ref of the git repository
+	// holding the root, and the path of the root relative to the
+	// top of that repository.  All are empty if the root is not
+	// in a cloned repository.
+	Repo() (cloneSpec, ref, dir string)
+	// Allow returns a Loader like this one that may also load
+	// files in or below the given directories, relative to the
+	// root, if its restrictions permit, or an error if they
+	// never do.
+	Allow(dirs []string) (Loader, error)
 	// Cleanup cleans the loader
 	Cleanup() error
 }
@@ -0,0 +1,338 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+)
+
+const (
+	// Directories within the cache.
+	checkoutsDir = "checkouts"
+	refsDir      = "refs"
+)
+
+var fullCommit = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// Cache keeps clones of repositories on disk, so
+// that they may be reused by later builds.
+//
+// A clone is kept in a directory named for a hash
+// of its clone spec and commit, so a ref that moves
+// to a new commit gets a new clone.  The commit
+// most recently seen for each ref is remembered,
+// which is what allows building offline.
+type Cache struct {
+	root    string
+	offline bool
+	cloner  Cloner
+	// If subPath is true, clones are kept per path
+	// in the repository, as well as per commit.
+	subPath bool
+	// mu guards seen and refLocks.  Clones may be
+	// obtained concurrently; those of the same ref
+	// hold its lock, so that one fills the cache,
+	// and the others find the clone in it.
+	mu       sync.Mutex
+	refLocks map[string]*sync.Mutex
+	// Commits of checkouts already found in this
+	// process, keyed by clone spec and ref.
+	seen map[string]string
+}
+
+// NewCache returns a cache in the given directory,
+// using the given cloner to fill it.  If offline
+// is true, repositories are never cloned, and a
+// repository not in the cache is an error.
+func NewCache(root string, offline bool, cloner Cloner) *Cache {
+	return &Cache{
+		root:     root,
+		offline:  offline,
+		cloner:   cloner,
+		refLocks: make(map[string]*sync.Mutex),
+		seen:     make(map[string]string),
+	}
+}
+
+// NewSubPathCache is like NewCache, for a cloner that
+// checks out only the repoSpec's Path, e.g. SubPathCloner.
+// The clones of different paths in a repository, at the
+// same commit, are kept apart.
+func NewSubPathCache(root string, offline bool, cloner Cloner) *Cache {
+	c := NewCache(root, offline, cloner)
+	c.subPath = true
+	return c
+}
+
+// Clone is a Cloner that obtains clones from the cache,
+// filling it as needed.  Clones obtained this way are
+// shared, and must not be removed by the caller; see
+// RepoSpec.Cleaner.
+func (c *Cache) Clone(repoSpec *RepoSpec) error {
+	if repoSpec.Ref == "" {
+		repoSpec.Ref = "master"
+	}
+	refKey := hashKey(c.cloneSpec(repoSpec), repoSpec.checkoutRef())
+	l := c.refLock(refKey)
+	l.Lock()
+	defer l.Unlock()
+	if commit, ok := c.seenCommit(refKey); ok {
+		c.checkout(repoSpec, commit)
+		return nil
+	}
+	commit, err := c.resolve(repoSpec, refKey)
+	if err != nil {
+		return err
+	}
+	if commit != "" {
+		dir := c.checkoutDir(repoSpec, commit)
+		if _, err := os.Stat(dir); err == nil {
+			c.remember(repoSpec, refKey, commit)
+			return nil
+		}
+	}
+	if c.offline {
+		return fmt.Errorf(
+			"offline; %s at ref '%s' is not in the cache at '%s'",
+			repoSpec.CloneSpec(), repoSpec.Ref, c.root)
+	}
+	return c.fill(repoSpec, refKey)
+}
+
+func (c *Cache) refLock(refKey string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.refLocks[refKey]
+	if !ok {
+		l = &sync.Mutex{}
+		c.refLocks[refKey] = l
+	}
+	return l
+}
+
+func (c *Cache) seenCommit(refKey string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	commit, ok := c.seen[refKey]
+	return commit, ok
+}
+
+// resolve returns the commit the ref refers to,
+// or the empty string if that cannot be known.
+func (c *Cache) resolve(repoSpec *RepoSpec, refKey string) (string, error) {
+	if fullCommit.MatchString(repoSpec.checkoutRef()) {
+		return repoSpec.checkoutRef(), nil
+	}
+	if c.offline {
+		content, err := ioutil.ReadFile(filepath.Join(c.root, refsDir, refKey))
+		if err != nil {
+			return "", nil
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return remoteCommit(repoSpec.FetchSpec(), repoSpec.Ref), nil
+}
+
+// fill clones the repository, moving the clone into the cache.
+func (c *Cache) fill(repoSpec *RepoSpec, refKey string) error {
+	err := c.cloner(repoSpec)
+	if err != nil {
+		return err
+	}
+	tmpDir := repoSpec.Dir
+	defer os.RemoveAll(tmpDir.String())
+	commit := repoSpec.Commit
+	if commit == "" {
+		commit, err = headCommit(tmpDir.String())
+		if err != nil {
+			return err
+		}
+	}
+	dir := c.checkoutDir(repoSpec, commit)
+	if _, err := os.Stat(dir); err != nil {
+		err = moveDir(tmpDir.String(), dir)
+		// A clone of the same commit, e.g. by another ref,
+		// may have been cached meanwhile.
+		if _, serr := os.Stat(dir); err != nil && serr != nil {
+			return errors.Wrapf(err, "caching clone of %s", repoSpec.CloneSpec())
+		}
+	}
+	c.remember(repoSpec, refKey, commit)
+	return nil
+}
+
+// remember records the commit of the ref, and
+// points the RepoSpec at the cached checkout.
+func (c *Cache) remember(repoSpec *RepoSpec, refKey, commit string) {
+	c.mu.Lock()
+	c.seen[refKey] = commit
+	c.mu.Unlock()
+	pinned := fullCommit.MatchString(repoSpec.checkoutRef())
+	c.checkout(repoSpec, commit)
+	if pinned {
+		return
+	}
+	refs := filepath.Join(c.root, refsDir)
+	if os.MkdirAll(refs, 0700) == nil {
+		// Failure here only means this ref can't be used
+		// offline; no reason to fail the build.
+		_ = ioutil.WriteFile(
+			filepath.Join(refs, refKey), []byte(commit+"\n"), 0600)
+	}
+}
+
+// checkout points the RepoSpec at the cached checkout of the commit.
+func (c *Cache) checkout(repoSpec *RepoSpec, commit string) {
+	repoSpec.Dir = filesys.ConfirmedDir(c.checkoutDir(repoSpec, commit))
+	repoSpec.Commit = commit
+	repoSpec.cached = true
+}
+
+func (c *Cache) checkoutDir(repoSpec *RepoSpec, commit string) string {
+	return filepath.Join(
+		c.root, checkoutsDir, hashKey(c.cloneSpec(repoSpec), commit))
+}
+
+// cloneSpec returns what, with a ref or commit,
+// distinguishes the clones in the cache.
+func (c *Cache) cloneSpec(repoSpec *RepoSpec) string {
+	if c.subPath {
+		return repoSpec.CloneSpec() + "//" + repoSpec.Path
+	}
+	return repoSpec.CloneSpec()
+}
+
+func hashKey(cloneSpec, ref string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(cloneSpec+"@"+ref)))
+}
+
+// lsRemote returns the commit of the ref in the remote
+// repository, or the empty string if it cannot be found.
+// An annotated tag names a tag object, so the commit
+// is taken from the peeled tag, listed as ref^{}.
+func lsRemote(fetchSpec, ref string) string {
+	out, err := runGit("", "ls-remote", fetchSpec, ref, ref+"^{}")
+	if err != nil {
+		return ""
+	}
+	result := ""
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !fullCommit.MatchString(fields[0]) {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0]
+		}
+		if result == "" {
+			result = fields[0]
+		}
+	}
+	return result
+}
+
+func headCommit(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", errors.Wrapf(err, "finding commit of clone in %s", dir)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	gitProgram, err := exec.LookPath("git")
+	if err != nil {
+		return "", errors.Wrap(err, "no 'git' program on path")
+	}
+	cmd := exec.Command(gitProgram, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Dir = dir
+	// These commands are only lookups; a failure just
+	// means a clone, which may prompt for credentials.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	err = cmd.Run()
+	return out.String(), err
+}
+
+// moveDir moves the directory, copying it if
+// it cannot be renamed, e.g. because the
+// destination is on another device.
+func moveDir(from, to string) error {
+	err := os.MkdirAll(filepath.Dir(to), 0700)
+	if err != nil {
+		return err
+	}
+	// Assemble the copy beside its destination, so
+	// that it appears there completely or not at all.
+	staging, err := ioutil.TempDir(filepath.Dir(to), ".staging-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	tmp := filepath.Join(staging, "checkout")
+	if os.Rename(from, tmp) != nil {
+		err = copyDir(from, tmp)
+		if err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, to)
+}
+
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(
+		path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(from, to string, mode os.FileMode) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
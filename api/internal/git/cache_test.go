@@ -0,0 +1,216 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+)
+
+// makeRepoCloner returns a cloner that makes a new
+// one-commit repository for each clone, counting clones.
+func makeRepoCloner(t *testing.T, count *int) Cloner {
+	return func(rs *RepoSpec) error {
+		*count++
+		dir, err := ioutil.TempDir("", "kustomize-cache-test-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(
+			filepath.Join(dir, "kustomization.yaml"), []byte("{}"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"add", "."},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com",
+				"commit", "-q", "-m", "init"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		rs.Dir = filesys.ConfirmedDir(dir)
+		return nil
+	}
+}
+
+func TestCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git program on path")
+	}
+	root, err := ioutil.TempDir("", "kustomize-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	url := "https://example.invalid/someOrg/someRepo//base?ref=v1"
+	count := 0
+	c := NewCache(root, false, makeRepoCloner(t, &count))
+
+	rs1, err := NewRepoSpecFromUrl(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Clone(rs1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rs1.Dir.HasPrefix(filesys.ConfirmedDir(root)) {
+		t.Fatalf("expected clone in %s, got %s", root, rs1.Dir)
+	}
+	rs2, _ := NewRepoSpecFromUrl(url)
+	if err = c.Clone(rs2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || rs2.Dir != rs1.Dir {
+		t.Fatalf("expected one shared clone, got %d clones", count)
+	}
+
+	// Cached clones survive cleaning.
+	if err = rs1.Cleaner(filesys.MakeFsOnDisk())(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(rs1.Dir.String()); err != nil {
+		t.Fatalf("expected cached clone to remain: %v", err)
+	}
+
+	// A later, offline build uses the cache.
+	c = NewCache(root, true, makeRepoCloner(t, &count))
+	rs3, _ := NewRepoSpecFromUrl(url)
+	if err = c.Clone(rs3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || rs3.Dir != rs1.Dir {
+		t.Fatalf("expected cached clone, got %d clones", count)
+	}
+	rs4, _ := NewRepoSpecFromUrl(
+		"https://example.invalid/someOrg/someRepo//base?ref=v2")
+	if err = c.Clone(rs4); err == nil {
+		t.Fatalf("expected error for uncached ref when offline")
+	}
+	if count != 1 {
+		t.Fatalf("expected no clone when offline, got %d clones", count)
+	}
+
+	// A ref pinned to a cached commit, e.g. by a lock,
+	// is checked out at that commit, keeping its ref.
+	rs5, _ := NewRepoSpecFromUrl(
+		"https://example.invalid/someOrg/someRepo//base?ref=v2")
+	rs5.Commit = rs1.Commit
+	if err = c.Clone(rs5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rs5.Dir != rs1.Dir || rs5.Ref != "v2" {
+		t.Fatalf("expected cached clone at ref v2, got %s at %s",
+			rs5.Dir, rs5.Ref)
+	}
+}
+
+func TestCacheConcurrentClones(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git program on path")
+	}
+	root, err := ioutil.TempDir("", "kustomize-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	url := "https://example.invalid/someOrg/someRepo//base?ref=v1"
+	count := 0
+	c := NewCache(root, false, makeRepoCloner(t, &count))
+
+	// Bases using the same repository may be
+	// accumulated, and so cloned, concurrently.
+	specs := make(chan *RepoSpec)
+	for i := 0; i < 4; i++ {
+		go func() {
+			rs, err := NewRepoSpecFromUrl(url)
+			if err == nil {
+				err = c.Clone(rs)
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			specs <- rs
+		}()
+	}
+	var dirs []filesys.ConfirmedDir
+	for i := 0; i < 4; i++ {
+		dirs = append(dirs, (<-specs).Dir)
+	}
+	if count != 1 {
+		t.Fatalf("expected one clone, got %d", count)
+	}
+	for _, d := range dirs {
+		if d != dirs[0] || !d.HasPrefix(filesys.ConfirmedDir(root)) {
+			t.Fatalf("expected one shared clone in %s, got %v", root, dirs)
+		}
+	}
+}
+
+func TestSubPathCache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git program on path")
+	}
+	root, err := ioutil.TempDir("", "kustomize-cache-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	count := 0
+	c := NewSubPathCache(root, false, makeRepoCloner(t, &count))
+	var dirs []filesys.ConfirmedDir
+	for _, path := range []string{"base", "other", "base"} {
+		rs, err := NewRepoSpecFromUrl(
+			"https://example.invalid/someOrg/someRepo//" + path + "?ref=v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = c.Clone(rs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dirs = append(dirs, rs.Dir)
+	}
+	// Each path has its own clone, shared by its bases.
+	if count != 2 || dirs[0] == dirs[1] || dirs[2] != dirs[0] {
+		t.Fatalf("expected a clone per path, got %d clones in %v", count, dirs)
+	}
+}
+
+func TestLsRemoteAnnotatedTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git program on path")
+	}
+	count := 0
+	rs := &RepoSpec{}
+	if err := makeRepoCloner(t, &count)(rs); err != nil {
+		t.Fatal(err)
+	}
+	dir := rs.Dir.String()
+	defer os.RemoveAll(dir)
+	cmd := exec.Command("git",
+		"-c", "user.name=test", "-c", "user.email=test@example.com",
+		"tag", "-a", "v1", "-m", "v1")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git tag: %v\n%s", err, out)
+	}
+	commit, err := headCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"v1", "HEAD"} {
+		if got := lsRemote(dir, ref); got != commit {
+			t.Errorf("expected %s at %s, got '%s'", ref, commit, got)
+		}
+	}
+}
@@ -15,6 +15,22 @@ import (
 // Cloner is a function that can clone a git repo.
 type Cloner func(repoSpec *RepoSpec) error
 
+// DefaultCloner is the Cloner used to obtain remote bases.
+// It's ClonerUsingGitExec, unless the program is built with
+// the gogit tag, in which case it's ClonerUsingGoGit, which
+// needs no git program.
+func DefaultCloner(repoSpec *RepoSpec) error {
+	return defaultCloner(repoSpec)
+}
+
+// SubPathCloner is like DefaultCloner, except that, in
+// programs built with the gogit tag, only the repoSpec's
+// Path is checked out; see SubPathClonerUsingGoGit.
+// Otherwise, the whole repository is.
+func SubPathCloner(repoSpec *RepoSpec) error {
+	return subPathCloner(repoSpec)
+}
+
 // ClonerUsingGitExec uses a local git install, as opposed
 // to say, some remote API, to obtain a local clone of
 // a remote repo.
@@ -48,7 +64,7 @@ func ClonerUsingGitExec(repoSpec *RepoSpec) error {
 		"remote",
 		"add",
 		"origin",
-		repoSpec.CloneSpec())
+		repoSpec.FetchSpec())
 	cmd.Stdout = &out
 	cmd.Stderr = &out
 	cmd.Dir = repoSpec.Dir.String()
@@ -58,7 +74,7 @@ func ClonerUsingGitExec(repoSpec *RepoSpec) error {
 		return errors.Wrapf(
 			err,
 			"trouble adding remote %s",
-			repoSpec.CloneSpec())
+			repoSpec.FetchSpec())
 	}
 	if repoSpec.Ref == "" {
 		repoSpec.Ref = "master"
@@ -68,14 +84,14 @@ func ClonerUsingGitExec(repoSpec *RepoSpec) error {
 		"fetch",
 		"--depth=1",
 		"origin",
-		repoSpec.Ref)
+		repoSpec.checkoutRef())
 	cmd.Stdout = &out
 	cmd.Stderr = &out
 	cmd.Dir = repoSpec.Dir.String()
 	err = cmd.Run()
 	if err != nil {
 		log.Printf("Error performing git fetch: %s", out.String())
-		return errors.Wrapf(err, "trouble fetching %s", repoSpec.Ref)
+		return errors.Wrapf(err, "trouble fetching %s", repoSpec.checkoutRef())
 	}
 
 	cmd = exec.Command(
@@ -106,7 +122,8 @@ func ClonerUsingGitExec(repoSpec *RepoSpec) error {
 		return errors.Wrapf(err, "trouble fetching submodules for %s", repoSpec.Ref)
 	}
 
-	return nil
+	repoSpec.Commit, err = headCommit(repoSpec.Dir.String())
+	return err
 }
 
 // DoNothingCloner returns a cloner that only sets
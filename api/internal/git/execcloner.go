@@ -0,0 +1,14 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// +build !gogit
+
+package git
+
+// Unless built with the gogit tag, remote bases are
+// obtained with the local git program.
+var (
+	defaultCloner = ClonerUsingGitExec
+	subPathCloner = ClonerUsingGitExec
+	remoteCommit  = lsRemote
+)
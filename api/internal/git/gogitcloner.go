@@ -0,0 +1,295 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// +build gogit
+
+package git
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+)
+
+// In programs built with the gogit tag, no git program
+// is needed to obtain remote bases.
+var (
+	defaultCloner = ClonerUsingGoGit
+	subPathCloner = SubPathClonerUsingGoGit
+	remoteCommit  = remoteCommitUsingGoGit
+)
+
+func init() {
+	// By default, go-git serves file urls by running
+	// git-upload-pack; serve them in process instead,
+	// so that local mirrors work without git.
+	client.InstallProtocol("file", server.DefaultServer)
+}
+
+// ClonerUsingGoGit uses go-git, a git implementation in
+// pure go, to obtain a local clone of a remote repo.
+// Only the ref is fetched, shallowly if possible.
+func ClonerUsingGoGit(repoSpec *RepoSpec) error {
+	return cloneUsingGoGit(repoSpec, false)
+}
+
+// SubPathClonerUsingGoGit is like ClonerUsingGoGit, except
+// that only the repoSpec's Path is checked out.  A base
+// cloned this way can't refer to files outside its Path,
+// and the clone mustn't be shared with bases at other
+// paths in the repository; see NewSubPathCache.
+func SubPathClonerUsingGoGit(repoSpec *RepoSpec) error {
+	return cloneUsingGoGit(repoSpec, true)
+}
+
+func cloneUsingGoGit(repoSpec *RepoSpec, subPathOnly bool) error {
+	var err error
+	repoSpec.Dir, err = filesys.NewTmpConfirmedDir()
+	if err != nil {
+		return err
+	}
+	if repoSpec.Ref == "" {
+		repoSpec.Ref = "master"
+	}
+	repo, err := gogit.PlainInit(repoSpec.Dir.String(), false)
+	if err != nil {
+		return errors.Wrapf(
+			err, "trouble initializing empty git repo in %s",
+			repoSpec.Dir.String())
+	}
+	remote, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{repoSpec.FetchSpec()},
+	})
+	if err != nil {
+		return errors.Wrapf(
+			err, "trouble adding remote %s", repoSpec.FetchSpec())
+	}
+	hash, err := fetchRef(repo, remote, repoSpec)
+	if err != nil {
+		return errors.Wrapf(err, "trouble fetching %s", repoSpec.Ref)
+	}
+	if subPathOnly {
+		err = checkoutPath(repo, hash, repoSpec.Dir.String(), repoSpec.Path)
+		if err != nil {
+			return errors.Wrapf(
+				err, "trouble checking out %s at %s", repoSpec.Path, repoSpec.Ref)
+		}
+	} else {
+		err = checkoutAll(repo, hash)
+		if err != nil {
+			return errors.Wrapf(err, "trouble checking out %s", repoSpec.Ref)
+		}
+	}
+	repoSpec.Commit = hash.String()
+	return nil
+}
+
+// fetchRef fetches the ref, a branch, tag or full commit
+// hash, returning the hash of the commit it refers to.
+func fetchRef(
+	repo *gogit.Repository, remote *gogit.Remote,
+	repoSpec *RepoSpec) (plumbing.Hash, error) {
+	opts := &gogit.FetchOptions{Tags: gogit.NoTags}
+	// The in process server for file urls doesn't
+	// support shallow fetches; those are local anyway.
+	if !strings.HasPrefix(repoSpec.FetchSpec(), "file://") {
+		opts.Depth = 1
+	}
+	if fullCommit.MatchString(repoSpec.checkoutRef()) {
+		// A commit can't be fetched by its hash, so
+		// fetch everything, and hope it's in there.
+		opts.Depth = 0
+		opts.RefSpecs = []config.RefSpec{
+			"+refs/heads/*:refs/remotes/origin/*",
+			"+refs/tags/*:refs/tags/*",
+		}
+		err := remote.Fetch(opts)
+		if err != nil && err != gogit.NoErrAlreadyUpToDate {
+			return plumbing.ZeroHash, err
+		}
+		return plumbing.NewHash(repoSpec.checkoutRef()), nil
+	}
+	refs, err := advertisedRefs(repoSpec.FetchSpec())
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	ref, _, err := findRemoteRef(refs, repoSpec.Ref)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	opts.RefSpecs = []config.RefSpec{
+		config.RefSpec("+" + ref.String() + ":" + ref.String())}
+	err = remote.Fetch(opts)
+	if err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return plumbing.ZeroHash, err
+	}
+	local, err := repo.Reference(ref, true)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	hash := local.Hash()
+	// Peel annotated tags.
+	if tag, err := repo.TagObject(hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		hash = commit.Hash
+	}
+	return hash, nil
+}
+
+// advertisedRefs returns the refs the remote advertises.
+func advertisedRefs(url string) (refs *packp.AdvRefs, err error) {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewClient(ep)
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.NewUploadPackSession(ep, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := s.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return s.AdvertisedReferences()
+}
+
+// findRemoteRef returns the full name of the branch or
+// tag among the refs with the given short name, and the
+// commit it refers to.  An annotated tag refers to a tag
+// object, so the commit is taken from the peeled tag, if
+// advertised; go-git's own server doesn't advertise those.
+func findRemoteRef(
+	refs *packp.AdvRefs, name string) (
+	plumbing.ReferenceName, plumbing.Hash, error) {
+	if name == plumbing.HEAD.String() && refs.Head != nil {
+		return plumbing.HEAD, *refs.Head, nil
+	}
+	for _, candidate := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(name),
+		plumbing.NewBranchReferenceName(name),
+		plumbing.NewTagReferenceName(name),
+	} {
+		hash, ok := refs.References[candidate.String()]
+		if !ok {
+			continue
+		}
+		if peeled, ok := refs.Peeled[candidate.String()]; ok {
+			hash = peeled
+		}
+		return candidate, hash, nil
+	}
+	return "", plumbing.ZeroHash,
+		errors.Errorf("no branch or tag named '%s'", name)
+}
+
+// remoteCommitUsingGoGit returns the commit of the ref in the
+// remote repository, or the empty string if it cannot be found.
+func remoteCommitUsingGoGit(fetchSpec, ref string) string {
+	refs, err := advertisedRefs(fetchSpec)
+	if err != nil {
+		return ""
+	}
+	_, hash, err := findRemoteRef(refs, ref)
+	if err != nil {
+		return ""
+	}
+	return hash.String()
+}
+
+func checkoutAll(repo *gogit.Repository, hash plumbing.Hash) error {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	err = wt.Checkout(&gogit.CheckoutOptions{Hash: hash, Force: true})
+	if err != nil {
+		return err
+	}
+	subs, err := wt.Submodules()
+	if err != nil {
+		return err
+	}
+	return subs.Update(&gogit.SubmoduleUpdateOptions{
+		Init:              true,
+		RecurseSubmodules: gogit.DefaultSubmoduleRecursionDepth,
+	})
+}
+
+// checkoutPath writes the files below the path in the
+// commit's tree into the same path below dir.
+func checkoutPath(
+	repo *gogit.Repository, hash plumbing.Hash, dir, path string) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	path = strings.Trim(path, "/")
+	if path != "" {
+		tree, err = tree.Tree(path)
+		if err != nil {
+			return err
+		}
+	}
+	return tree.Files().ForEach(func(f *object.File) error {
+		target := filepath.Join(dir, path, filepath.FromSlash(f.Name))
+		err := os.MkdirAll(filepath.Dir(target), 0700)
+		if err != nil {
+			return err
+		}
+		if f.Mode == filemode.Symlink {
+			link, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		mode, err := f.Mode.ToOSFileMode()
+		if err != nil {
+			return err
+		}
+		return writeBlob(f, target, mode)
+	})
+}
+
+func writeBlob(f *object.File, target string, mode os.FileMode) error {
+	in, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// +build gogit
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+)
+
+// makeRepo makes a repository with one commit,
+// returning the repository's dir and the commit.
+func makeRepo(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "kustomize-gogit-test-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Write the config, without which it's not served.
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = repo.SetConfig(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for path, content := range map[string]string{
+		"base/kustomization.yaml":  "resources:\n- service.yaml\n",
+		"base/service.yaml":        "kind: Service\n",
+		"other/kustomization.yaml": "namePrefix: other-\n",
+	} {
+		full := filepath.Join(dir, path)
+		if err = os.MkdirAll(filepath.Dir(full), 0700); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = ioutil.WriteFile(full, []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = wt.AddGlob("."); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hash, err := wt.Commit("initial", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return dir, hash.String()
+}
+
+func TestClonerUsingGoGit(t *testing.T) {
+	dir, commit := makeRepo(t)
+	defer os.RemoveAll(dir)
+	for _, ref := range []string{"", "master", commit} {
+		rs, err := NewRepoSpecFromUrl(
+			"github.com/someOrg/someRepo//base?ref=" + ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rs.MirrorUrl = "file://" + dir + "/.git"
+		if err = ClonerUsingGoGit(rs); err != nil {
+			t.Fatalf("ref '%s': unexpected error: %v", ref, err)
+		}
+		if rs.Commit != commit {
+			t.Errorf("ref '%s': expected commit %s, got %s", ref, commit, rs.Commit)
+		}
+		for _, path := range []string{
+			"base/service.yaml", "other/kustomization.yaml"} {
+			if _, err = os.Stat(rs.Dir.Join(path)); err != nil {
+				t.Errorf("ref '%s': expected %s in clone", ref, path)
+			}
+		}
+		os.RemoveAll(rs.Dir.String())
+	}
+	if remoteCommitUsingGoGit("file://"+dir+"/.git", "master") != commit {
+		t.Errorf("expected master to be at %s", commit)
+	}
+}
+
+func TestSubPathClonerUsingGoGit(t *testing.T) {
+	dir, _ := makeRepo(t)
+	defer os.RemoveAll(dir)
+	rs, err := NewRepoSpecFromUrl("github.com/someOrg/someRepo//base")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rs.MirrorUrl = "file://" + dir + "/.git"
+	if err = SubPathClonerUsingGoGit(rs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(rs.Dir.String())
+	if _, err = os.Stat(rs.AbsPath() + "/service.yaml"); err != nil {
+		t.Errorf("expected base in clone")
+	}
+	if _, err = os.Stat(rs.Dir.Join("other")); err == nil {
+		t.Errorf("expected only base in clone")
+	}
+}
+
+func TestFindRemoteRef(t *testing.T) {
+	commit := plumbing.NewHash("1111111111111111111111111111111111111111")
+	tag := plumbing.NewHash("2222222222222222222222222222222222222222")
+	refs := packp.NewAdvRefs()
+	refs.Head = &commit
+	refs.References["refs/heads/master"] = commit
+	refs.References["refs/tags/v1"] = commit
+	refs.References["refs/tags/v2"] = tag
+	refs.Peeled["refs/tags/v2"] = commit
+	for ref, expected := range map[string]plumbing.ReferenceName{
+		"HEAD":              plumbing.HEAD,
+		"master":            "refs/heads/master",
+		"refs/heads/master": "refs/heads/master",
+		"v1":                "refs/tags/v1",
+		"v2":                "refs/tags/v2",
+	} {
+		name, hash, err := findRemoteRef(refs, ref)
+		if err != nil {
+			t.Fatalf("ref '%s': unexpected error: %v", ref, err)
+		}
+		if name != expected || hash != commit {
+			t.Errorf("ref '%s': expected %s at %s, got %s at %s",
+				ref, expected, commit, name, hash)
+		}
+	}
+	if _, _, err := findRemoteRef(refs, "v3"); err == nil {
+		t.Errorf("expected error for unknown ref")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"strings"
+)
+
+// Mirrors maps repository names, as returned by RepoSpec.Name,
+// to the urls of mirrors to fetch them from instead, e.g.
+//
+//	github.com/someOrg/someRepo: file:///mirrors/someRepo.git
+//
+// A name may also be a prefix of repository names, ending
+// at a '/', in which case the rest of a repository's name
+// is appended to the url, e.g.
+//
+//	github.com/someOrg: https://git.example.com/someOrg
+//
+// mirrors github.com/someOrg/someRepo at
+// https://git.example.com/someOrg/someRepo.
+// The longest matching name is used.
+type Mirrors map[string]string
+
+// Apply sets the MirrorUrl of the RepoSpec,
+// if its repository has a mirror.
+func (m Mirrors) Apply(repoSpec *RepoSpec) {
+	name := repoSpec.Name()
+	longest := ""
+	for k, url := range m {
+		prefix := normalizeName(k)
+		if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+		if repoSpec.MirrorUrl == "" || len(prefix) > len(longest) {
+			longest = prefix
+			repoSpec.MirrorUrl =
+				strings.TrimSuffix(url, "/") + name[len(prefix):]
+		}
+	}
+}
+
+// Wrap returns a Cloner that applies the mirrors
+// to a RepoSpec before cloning it.
+func (m Mirrors) Wrap(cloner Cloner) Cloner {
+	if len(m) == 0 {
+		return cloner
+	}
+	return func(repoSpec *RepoSpec) error {
+		m.Apply(repoSpec)
+		return cloner(repoSpec)
+	}
+}
+
+// normalizeName strips a protocol from a name, so that
+// names in Mirrors may be written as urls.
+func normalizeName(name string) string {
+	return strings.TrimSuffix(stripProtocol(name), gitSuffix)
+}
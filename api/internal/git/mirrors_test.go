@@ -0,0 +1,60 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"testing"
+)
+
+func TestMirrorsApply(t *testing.T) {
+	mirrors := Mirrors{
+		"github.com/someOrg":                    "https://git.example.com/someOrg/",
+		"https://github.com/someOrg/someRepo":   "file:///mirrors/someRepo.git",
+		"git@gitlab.com:otherOrg/otherRepo.git": "file:///mirrors/otherRepo.git",
+	}
+	testCases := map[string]string{
+		"github.com/someOrg/someRepo//base?ref=v1":     "file:///mirrors/someRepo.git",
+		"git@github.com:someOrg/someRepo.git//base":    "file:///mirrors/someRepo.git",
+		"github.com/someOrg/someRepoToo/base":          "https://git.example.com/someOrg/someRepoToo",
+		"https://gitlab.com/otherOrg/otherRepo.git//b": "file:///mirrors/otherRepo.git",
+		"github.com/otherOrg/otherRepo/base":           "",
+		"github.com/someOrganization/someRepo//base":   "",
+	}
+	for url, expected := range testCases {
+		rs, err := NewRepoSpecFromUrl(url)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mirrors.Apply(rs)
+		if rs.MirrorUrl != expected {
+			t.Errorf("%s: expected mirror '%s', got '%s'", url, expected, rs.MirrorUrl)
+		}
+		if expected == "" && rs.FetchSpec() != rs.CloneSpec() {
+			t.Errorf("%s: expected to fetch from %s, got %s",
+				url, rs.CloneSpec(), rs.FetchSpec())
+		}
+	}
+}
+
+func TestMirrorsWrap(t *testing.T) {
+	var fetched string
+	cloner := Mirrors{"github.com/someOrg": "file:///mirrors"}.Wrap(
+		func(rs *RepoSpec) error {
+			fetched = rs.FetchSpec()
+			return nil
+		})
+	rs, err := NewRepoSpecFromUrl("github.com/someOrg/someRepo/base")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = cloner(rs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetched != "file:///mirrors/someRepo" {
+		t.Errorf("unexpected fetch spec %s", fetched)
+	}
+	if rs.CloneSpec() != "https://github.com/someOrg/someRepo.git" {
+		t.Errorf("unexpected clone spec %s", rs.CloneSpec())
+	}
+}
@@ -42,8 +42,21 @@ type RepoSpec struct {
 	// Branch or tag reference.
 	Ref string
 
+	// If set before cloning, e.g. by a lock, the commit
+	// to check out instead of the one Ref refers to.
+	// Set by cloners to the commit checked out in Dir,
+	// if known.
+	Commit string
+
 	// e.g. .git or empty in case of _git is present
 	GitSuffix string
+
+	// If set, the url of a mirror of the repository,
+	// to fetch from instead; see Mirrors.
+	MirrorUrl string
+
+	// True if Dir is a shared clone held in a Cache.
+	cached bool
 }
 
 // CloneSpec returns a string suitable for "git clone {spec}".
@@ -54,6 +67,42 @@ func (x *RepoSpec) CloneSpec() string {
 	return x.Host + x.OrgRepo + x.GitSuffix
 }
 
+// FetchSpec returns the url to fetch the repository from,
+// which is its mirror if it has one, else its CloneSpec.
+func (x *RepoSpec) FetchSpec() string {
+	if x.MirrorUrl != "" {
+		return x.MirrorUrl
+	}
+	return x.CloneSpec()
+}
+
+// checkoutRef returns what to check out: the
+// pinned commit if there is one, else the ref.
+func (x *RepoSpec) checkoutRef() string {
+	if x.Commit != "" {
+		return x.Commit
+	}
+	return x.Ref
+}
+
+// Name returns the host and orgRepo of the repository,
+// without the protocol used to reach it, e.g.
+// github.com/someOrg/someRepo.
+func (x *RepoSpec) Name() string {
+	return stripProtocol(x.Host) + "/" + strings.Trim(x.OrgRepo, "/")
+}
+
+// stripProtocol removes the protocol from a url,
+// e.g. git@github.com:someOrg becomes github.com/someOrg.
+func stripProtocol(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+len("://"):]
+	}
+	url = strings.TrimPrefix(url, "git@")
+	url = strings.Replace(url, ":", "/", -1)
+	return strings.Trim(url, "/")
+}
+
 func (x *RepoSpec) CloneDir() filesys.ConfirmedDir {
 	return x.Dir
 }
@@ -66,8 +115,15 @@ func (x *RepoSpec) AbsPath() string {
 	return x.Dir.Join(x.Path)
 }
 
+// Cleaner returns a function that removes the clone,
+// unless the clone is held in a Cache.
 func (x *RepoSpec) Cleaner(fSys filesys.FileSystem) func() error {
-	return func() error { return fSys.RemoveAll(x.Dir.String()) }
+	return func() error {
+		if x.cached {
+			return nil
+		}
+		return fSys.RemoveAll(x.Dir.String())
+	}
 }
 
 // From strings like git@github.com:someOrg/someRepo.git or
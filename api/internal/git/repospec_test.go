@@ -286,3 +286,21 @@ func TestIsAWSHost(t *testing.T) {
 		}
 	}
 }
+
+func TestName(t *testing.T) {
+	testCases := map[string]string{
+		"github.com/someOrg/someRepo//base":              "github.com/someOrg/someRepo",
+		"git@github.com:someOrg/someRepo.git//base":      "github.com/someOrg/someRepo",
+		"ssh://git.example.com:7999/someOrg/someRepo":    "git.example.com/7999/someOrg/someRepo",
+		"https://gitlab.com/someOrg/someRepo.git?ref=v1": "gitlab.com/someOrg/someRepo",
+	}
+	for url, expected := range testCases {
+		rs, err := NewRepoSpecFromUrl(url)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rs.Name() != expected {
+			t.Errorf("%s: expected name %s, got %s", url, expected, rs.Name())
+		}
+	}
+}
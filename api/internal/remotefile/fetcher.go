@@ -0,0 +1,248 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package remotefile fetches single files, such as
+// release manifests, from http(s) urls.
+package remotefile
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// A url may end with this, followed by the hex
+	// sha256 of the file, to pin the file's content.
+	sumFragment = "#sha256="
+
+	// Directories within the cache.
+	contentDir = "content"
+	urlsDir    = "urls"
+
+	// MaxFileSize is the most bytes fetched for a
+	// remote file.  It keeps a server, by mistake or
+	// otherwise, from exhausting memory.
+	MaxFileSize = 64 << 20
+
+	// DefaultTimeout limits the time taken to fetch a
+	// remote file, if NewFetcher isn't given a client.
+	DefaultTimeout = 2 * time.Minute
+)
+
+var (
+	fileExtensions = []string{".yaml", ".yml", ".json"}
+	sha256Sum      = regexp.MustCompile("^[0-9a-f]{64}$")
+)
+
+// IsRemoteFile returns true if the location is
+// an http(s) url of a single file, meaning it's
+// pinned with a sha256, or names a YAML or JSON file,
+// e.g. https://example.com/release.yaml#sha256=9f86...
+// Parse rejects a plain http url that isn't pinned.
+func IsRemoteFile(location string) bool {
+	lower := strings.ToLower(location)
+	if !strings.HasPrefix(lower, "https://") &&
+		!strings.HasPrefix(lower, "http://") {
+		return false
+	}
+	if strings.Contains(lower, sumFragment) {
+		return true
+	}
+	if i := strings.IndexAny(lower, "?#"); i >= 0 {
+		lower = lower[:i]
+	}
+	for _, ext := range fileExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse splits the location into the url to fetch
+// and the sha256 the content is pinned to, if any.
+// Since nothing assures the content of a file fetched
+// with plain http, such a file must be pinned.
+func Parse(location string) (url, sum string, err error) {
+	i := strings.Index(location, sumFragment)
+	if i < 0 {
+		if !strings.HasPrefix(strings.ToLower(location), "https://") {
+			return "", "", fmt.Errorf(
+				"'%s' must use https, or be pinned with %s<sum>",
+				location, sumFragment)
+		}
+		return location, "", nil
+	}
+	url, sum = location[:i], strings.ToLower(location[i+len(sumFragment):])
+	if !sha256Sum.MatchString(sum) {
+		return "", "", fmt.Errorf(
+			"'%s' is not a sha256; expecting 64 hex digits in '%s'",
+			sum, location)
+	}
+	return url, sum, nil
+}
+
+// Fetcher fetches remote files, keeping them in a cache
+// on disk so they may be reused by later builds.
+//
+// A file pinned to a sha256 is kept under its sum, and
+// once cached is never fetched again.  Other files are
+// fetched anew by every Fetcher, i.e. by every build, but
+// the file most recently fetched from each url is kept,
+// which is what allows building offline.  A Fetcher
+// fetches each url at most once.
+type Fetcher struct {
+	client  *http.Client
+	root    string
+	offline bool
+	maxSize int64
+	mu      sync.Mutex
+	fetched map[string][]byte
+}
+
+// NewFetcher returns a Fetcher using the given client,
+// or if that's nil, one that times out after
+// DefaultTimeout, caching files in the given directory.
+// If root is
+// empty, nothing is cached.  If offline is true, files
+// are never fetched, and a file not in the cache is an
+// error.
+func NewFetcher(client *http.Client, root string, offline bool) *Fetcher {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Fetcher{
+		client:  client,
+		root:    root,
+		offline: offline,
+		maxSize: MaxFileSize,
+		fetched: make(map[string][]byte),
+	}
+}
+
+// Fetch returns the content of the remote file at
+// the location, failing if it's pinned to a sha256
+// and the content doesn't match.
+func (f *Fetcher) Fetch(location string) ([]byte, error) {
+	f.mu.Lock()
+	content, ok := f.fetched[location]
+	f.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+	content, err := f.fetch(location)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.fetched[location] = content
+	f.mu.Unlock()
+	return content, nil
+}
+
+func (f *Fetcher) fetch(location string) ([]byte, error) {
+	url, sum, err := Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if sum != "" {
+		content, err := f.read(contentDir, sum)
+		if err == nil && sumOf(content) == sum {
+			return content, nil
+		}
+	}
+	urlKey := sumOf([]byte(url))
+	var content []byte
+	if f.offline {
+		content, err = f.read(urlsDir, urlKey)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"offline; %s is not in the cache at '%s'", url, f.root)
+		}
+	} else {
+		content, err = f.get(url)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sum != "" && sumOf(content) != sum {
+		return nil, fmt.Errorf(
+			"sha256 of %s is %s, but it's pinned to %s",
+			url, sumOf(content), sum)
+	}
+	// Failure here only means the file can't be
+	// used offline; no reason to fail the build.
+	_ = f.write(urlsDir, urlKey, content)
+	if sum != "" {
+		_ = f.write(contentDir, sum, content)
+	}
+	return content, nil
+}
+
+func (f *Fetcher) get(url string) ([]byte, error) {
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, f.maxSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", url)
+	}
+	if int64(len(content)) > f.maxSize {
+		return nil, fmt.Errorf(
+			"fetching %s: more than %d bytes", url, f.maxSize)
+	}
+	return content, nil
+}
+
+func (f *Fetcher) read(dir, key string) ([]byte, error) {
+	if f.root == "" {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(filepath.Join(f.root, dir, key))
+}
+
+// write writes the file into the cache, by way of a
+// temporary file, so that readers never see part of it.
+func (f *Fetcher) write(dir, key string, content []byte) error {
+	if f.root == "" {
+		return nil
+	}
+	dir = filepath.Join(f.root, dir)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, key))
+}
+
+func sumOf(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
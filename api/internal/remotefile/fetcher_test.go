@@ -0,0 +1,153 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remotefile
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const release = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: vendor-system
+`
+
+// A sha256, but not that of release.
+const otherSum = "0d8b1e53a6f5b3ab9ff1d04f3b2a2db1e3ee1d7ebd2fc5f93a9e0ea2b2f1b5ab"
+
+func TestIsRemoteFile(t *testing.T) {
+	testCases := map[string]bool{
+		"https://example.com/release.yaml":                     true,
+		"http://example.com/v1/release.yml?raw=true":           true,
+		"https://example.com/release.json":                     true,
+		"https://example.com/download#sha256=" + otherSum:      true,
+		"https://github.com/someOrg/someRepo//base":            false,
+		"https://github.com/someOrg/someRepo/base?ref=v1.yaml": false,
+		"release.yaml": false,
+		"github.com/someOrg/someRepo/release.yaml": false,
+	}
+	for location, expected := range testCases {
+		if IsRemoteFile(location) != expected {
+			t.Errorf("%s: expected %v", location, expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	url, sum, err := Parse("https://example.com/release.yaml#sha256=" +
+		strings.ToUpper(otherSum))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url != "https://example.com/release.yaml" || sum != otherSum {
+		t.Fatalf("unexpected url %s and sum %s", url, sum)
+	}
+	_, _, err = Parse("https://example.com/release.yaml#sha256=abc")
+	if err == nil || !strings.Contains(err.Error(), "not a sha256") {
+		t.Fatalf("expected error about sha256, got %v", err)
+	}
+	_, _, err = Parse("http://example.com/release.yaml")
+	if err == nil || !strings.Contains(err.Error(), "must use https") {
+		t.Fatalf("expected error about http, got %v", err)
+	}
+	url, _, err = Parse("http://example.com/release.yaml#sha256=" + otherSum)
+	if err != nil || url != "http://example.com/release.yaml" {
+		t.Fatalf("unexpected url %s and error %v", url, err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Path != "/release.yaml" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(release))
+		}))
+	defer server.Close()
+	root, err := ioutil.TempDir("", "kustomize-remotefile-test-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(root)
+	f := NewFetcher(server.Client(), root, false)
+	url := server.URL + "/release.yaml"
+	sum := sumOf([]byte(release))
+
+	content, err := f.Fetch(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != release {
+		t.Fatalf("unexpected content %s", content)
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+
+	// A pinned file is fetched once, then taken from the cache.
+	for i := 0; i < 2; i++ {
+		if _, err = f.Fetch(url + sumFragment + sum); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+
+	_, err = f.Fetch(url + sumFragment + otherSum)
+	if err == nil || !strings.Contains(err.Error(), "pinned to "+otherSum) {
+		t.Fatalf("expected mismatch error, got %v", err)
+	}
+
+	_, err = f.Fetch(server.URL + "/missing.yaml")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	// Offline, files come from the cache.
+	requests = 0
+	offline := NewFetcher(server.Client(), root, true)
+	content, err = offline.Fetch(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != release {
+		t.Fatalf("unexpected content %s", content)
+	}
+	_, err = offline.Fetch(server.URL + "/other.yaml")
+	if err == nil || !strings.HasPrefix(err.Error(), "offline;") {
+		t.Fatalf("expected offline error, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests offline, got %d", requests)
+	}
+}
+
+func TestFetchTooLarge(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(release))
+		}))
+	defer server.Close()
+	f := NewFetcher(server.Client(), "", false)
+	f.maxSize = int64(len(release))
+	if _, err := f.Fetch(server.URL + "/release.yaml"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f = NewFetcher(server.Client(), "", false)
+	f.maxSize = int64(len(release)) - 1
+	_, err := f.Fetch(server.URL + "/release.yaml")
+	if err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"sigs.k8s.io/kustomize/v3/api/filesys"
 	"sigs.k8s.io/kustomize/v3/api/ifc"
 	"sigs.k8s.io/kustomize/v3/api/internal/git"
+	"sigs.k8s.io/kustomize/v3/api/internal/remotefile"
 )
 
 // fileLoader is a kustomization's interface to files.
@@ -45,6 +46,11 @@ import (
 //   cloned, and the new loader is rooted on a path
 //   in that clone.
 //
+//   A base can also be a directory in a local
+//   archive, e.g. 'bases/platform.tgz//overlays/prod'.
+//   The new loader reads files from the archive
+//   alone, rooted on that directory within it.
+//
 //   As loaders create new loaders, a root history
 //   is established, and used to disallow:
 //
@@ -79,18 +85,37 @@ type fileLoader struct {
 	// Restricts behavior of Load function.
 	loadRestrictor LoadRestrictorFunc
 
+	// Absolute directories, besides those permitted by
+	// the loadRestrictor, from which Load may read, if
+	// the loadRestrictor is made by RestrictionAllowDirs.
+	// Not inherited by new loaders; see Allow.
+	allowed []string
+
 	// If this is non-nil, the files were
 	// obtained from the given repository.
 	repoSpec *git.RepoSpec
 
+	// If this is non-empty, the files were read from
+	// the archive at this path, which fSys presents
+	// as a directory.
+	archive filesys.ConfirmedDir
+
 	// File system utilities.
 	fSys filesys.FileSystem
 
 	// Used to clone repositories.
 	cloner git.Cloner
 
+	// Used to fetch remote files.  If nil,
+	// the referrer's fetcher is used.
+	fetcher *remotefile.Fetcher
+
 	// Used to clean up, as needed.
 	cleaner func() error
+
+	// If non-nil, records the local files and directories
+	// read.  If nil, the referrer's recorder is used.
+	recorder *Recorder
 }
 
 const CWD = "."
@@ -123,7 +148,7 @@ func newLoaderOrDie(
 		log.Fatalf("unable to make loader at '%s'; %v", path, err)
 	}
 	return newLoaderAtConfirmedDir(
-		lr, root, fSys, nil, git.ClonerUsingGitExec)
+		lr, root, fSys, nil, git.DefaultCloner)
 }
 
 // newLoaderAtConfirmedDir returns a new fileLoader with given root.
@@ -162,19 +187,36 @@ func demandDirectoryRoot(
 }
 
 // New returns a new Loader, rooted relative to current loader,
-// or rooted in a temp directory holding a git repo clone.
+// or rooted in a temp directory holding a git repo clone,
+// or rooted in an archive.
 func (fl *fileLoader) New(path string) (ifc.Loader, error) {
 	if path == "" {
 		return nil, fmt.Errorf("new root cannot be empty")
 	}
+	if remotefile.IsRemoteFile(path) {
+		return nil, fmt.Errorf(
+			"'%s' is a remote file; expecting directory", path)
+	}
+	if archive, dir, ok := splitArchivePath(path); ok {
+		ldr, err := fl.newLoaderInArchive(archive, dir)
+		if err != nil {
+			return nil, err
+		}
+		ldr.recordDir()
+		return ldr, nil
+	}
 	repoSpec, err := git.NewRepoSpecFromUrl(path)
 	if err == nil {
 		// Treat this as git repo clone request.
 		if err := fl.errIfRepoCycle(repoSpec); err != nil {
 			return nil, err
 		}
-		return newLoaderAtGitClone(
-			repoSpec, fl.fSys, fl, fl.cloner)
+		ldr, err := newLoaderAtGitClone(
+			repoSpec, fl.hostFSys(), fl, fl.cloner)
+		if err != nil {
+			return nil, err
+		}
+		return ldr, nil
 	}
 	if filepath.IsAbs(path) {
 		return nil, fmt.Errorf("new root '%s' cannot be absolute", path)
@@ -186,18 +228,119 @@ func (fl *fileLoader) New(path string) (ifc.Loader, error) {
 	if err := fl.errIfGitContainmentViolation(root); err != nil {
 		return nil, err
 	}
+	if err := fl.errIfArchiveContainmentViolation(root); err != nil {
+		return nil, err
+	}
 	if err := fl.errIfArgEqualOrHigher(root); err != nil {
 		return nil, err
 	}
-	return newLoaderAtConfirmedDir(
-		fl.loadRestrictor, root, fl.fSys, fl, fl.cloner), nil
+	ldr := newLoaderAtConfirmedDir(
+		fl.loadRestrictor, root, fl.fSys, fl, fl.cloner)
+	ldr.recordDir()
+	return ldr, nil
+}
+
+// splitArchivePath splits a path such as
+// 'bases/platform.tgz//overlays/prod' into the
+// path of the archive and the directory within it.
+// It returns false if the path isn't in an archive.
+func splitArchivePath(path string) (archive, dir string, ok bool) {
+	archive = path
+	if i := strings.Index(path, "//"); i >= 0 {
+		archive, dir = path[:i], path[i+2:]
+	}
+	return archive, dir, filesys.IsArchiveName(archive)
+}
+
+// newLoaderInArchive returns a new Loader rooted
+// on the given directory in the given archive.
+func (fl *fileLoader) newLoaderInArchive(
+	archive, dir string) (*fileLoader, error) {
+	if filepath.IsAbs(archive) {
+		return nil, fmt.Errorf(
+			"archive '%s' cannot be absolute", archive)
+	}
+	d, f, err := fl.fSys.CleanedAbs(fl.root.Join(archive))
+	if err != nil {
+		return nil, err
+	}
+	if f == "" {
+		return nil, fmt.Errorf(
+			"'%s' is a directory; expecting archive", archive)
+	}
+	mount := filesys.ConfirmedDir(d.Join(f))
+	if err := fl.errIfGitContainmentViolation(mount); err != nil {
+		return nil, err
+	}
+	if err := fl.errIfArchiveContainmentViolation(mount); err != nil {
+		return nil, err
+	}
+	content, err := fl.fSys.ReadFile(mount.String())
+	if err != nil {
+		return nil, err
+	}
+	fSys, err := filesys.MakeFsInArchive(mount, f, content)
+	if err != nil {
+		return nil, err
+	}
+	root, err := demandDirectoryRoot(fSys, mount.Join(dir))
+	if err != nil {
+		return nil, err
+	}
+	return &fileLoader{
+		// Archives never allowed to escape root.
+		loadRestrictor: RestrictionRootOnly,
+		root:           root,
+		referrer:       fl,
+		archive:        mount,
+		fSys:           fSys,
+		cloner:         fl.cloner,
+		cleaner:        func() error { return nil },
+	}, nil
+}
+
+func (fl *fileLoader) errIfArchiveContainmentViolation(
+	base filesys.ConfirmedDir) error {
+	archive := fl.containingArchive()
+	if archive == nil {
+		return nil
+	}
+	if !base.HasPrefix(archive.archive) {
+		return fmt.Errorf(
+			"security; bases in kustomizations found in "+
+				"archives must be within the archive, "+
+				"but base '%s' is outside '%s'",
+			base, archive.archive)
+	}
+	return nil
+}
+
+// Looks back through referrers for the loader that opened
+// an archive, returning nil if none found.
+func (fl *fileLoader) containingArchive() *fileLoader {
+	if fl.archive != "" {
+		return fl
+	}
+	if fl.referrer == nil || fl.repoSpec != nil {
+		return nil
+	}
+	return fl.referrer.containingArchive()
+}
+
+// hostFSys returns the file system holding the
+// root, or the archive holding the root.
+func (fl *fileLoader) hostFSys() filesys.FileSystem {
+	if archive := fl.containingArchive(); archive != nil {
+		return archive.referrer.hostFSys()
+	}
+	return fl.fSys
 }
 
 // newLoaderAtGitClone returns a new Loader pinned to a temporary
 // directory holding a cloned git repo.
 func newLoaderAtGitClone(
 	repoSpec *git.RepoSpec, fSys filesys.FileSystem,
-	referrer *fileLoader, cloner git.Cloner) (ifc.Loader, error) {
+	referrer *fileLoader, cloner git.Cloner) (*fileLoader, error) {
 	cleaner := repoSpec.Cleaner(fSys)
 	err := cloner(repoSpec)
 	if err != nil {
@@ -259,6 +402,22 @@ func (fl *fileLoader) containingRepo() *git.RepoSpec {
 	return fl.referrer.containingRepo()
 }
 
+// Repo implements ifc.Loader.
+func (fl *fileLoader) Repo() (cloneSpec, ref, dir string) {
+	repoSpec := fl.containingRepo()
+	if repoSpec == nil {
+		return "", "", ""
+	}
+	dir, err := filepath.Rel(
+		repoSpec.CloneDir().String(), fl.root.String())
+	if err != nil {
+		// Can't happen; containment is
+		// enforced when loaders are made.
+		dir = fl.root.String()
+	}
+	return repoSpec.CloneSpec(), repoSpec.Ref, dir
+}
+
 // errIfArgEqualOrHigher tests whether the argument,
 // is equal to or above the root of any ancestor.
 func (fl *fileLoader) errIfArgEqualOrHigher(
@@ -299,13 +458,88 @@ func (fl *fileLoader) Load(path string) ([]byte, error) {
 	if !filepath.IsAbs(path) {
 		path = fl.root.Join(path)
 	}
-	path, err := fl.loadRestrictor(fl.fSys, fl.root, path)
+	path, err := fl.restrict(path)
 	if err != nil {
 		return nil, err
 	}
+	fl.recordPath(path)
 	return fl.fSys.ReadFile(path)
 }
 
+// LoadRemoteFile returns the content of the remote
+// file at the url, which may pin it to a sha256.
+func (fl *fileLoader) LoadRemoteFile(url string) ([]byte, error) {
+	if !remotefile.IsRemoteFile(url) {
+		return nil, fmt.Errorf("'%s' is not the url of a remote file", url)
+	}
+	return fl.remoteFiles().Fetch(url)
+}
+
+func (fl *fileLoader) restrict(path string) (string, error) {
+	p, err := fl.loadRestrictor(fl.fSys, fl.root, path)
+	if err == nil || len(fl.allowed) == 0 {
+		return p, err
+	}
+	if e, ok := err.(*notAllowedError); ok {
+		dirs := append(append([]string(nil), e.dirs...), fl.allowed...)
+		return restrictToDirs(fl.fSys, fl.root, path, dirs)
+	}
+	if _, aerr := restrictToDirs(
+		fl.fSys, fl.root, path, fl.allowed); aerr == nil {
+		return "", fmt.Errorf(
+			"%v; '%s' allows %v, which requires --%s %s",
+			err, fl.root, fl.allowed, flagName, allowlist)
+	}
+	return p, err
+}
+
+// Allow returns a Loader like this one that may also load
+// files in or below the given directories, which are taken
+// relative to the root.  Only a restrictor made by
+// RestrictionAllowDirs lets it do so; others decide
+// alone what it loads.  Only the kustomization being
+// built may allow directories, i.e. not its bases, which
+// may be copies of remote ones, nor one in a cloned repo.
+func (fl *fileLoader) Allow(dirs []string) (ifc.Loader, error) {
+	if len(dirs) == 0 {
+		return fl, nil
+	}
+	if fl.containingRepo() != nil || fl.containingArchive() != nil {
+		return nil, fmt.Errorf(
+			"security; kustomizations found in cloned git repos "+
+				"or archives may not allow directories, but '%s' allows %v",
+			fl.root, dirs)
+	}
+	if fl.referrer != nil {
+		return nil, fmt.Errorf(
+			"security; only the kustomization being built "+
+				"may allow directories, but its base '%s' allows %v",
+			fl.root, dirs)
+	}
+	result := *fl
+	result.allowed = append([]string(nil), fl.allowed...)
+	for _, d := range dirs {
+		if !filepath.IsAbs(d) {
+			d = fl.root.Join(d)
+		}
+		result.allowed = append(result.allowed, d)
+	}
+	return &result, nil
+}
+
+// remoteFiles returns the fetcher of remote files, which
+// is shared by all the loaders in a build.  Without one,
+// remote files are fetched without caching.
+func (fl *fileLoader) remoteFiles() *remotefile.Fetcher {
+	if fl.fetcher != nil {
+		return fl.fetcher
+	}
+	if fl.referrer != nil {
+		return fl.referrer.remoteFiles()
+	}
+	return remotefile.NewFetcher(nil, "", false)
+}
+
 // Cleanup runs the cleaner.
 func (fl *fileLoader) Cleanup() error {
 	return fl.cleaner()
@@ -17,6 +17,9 @@ limitations under the License.
 package loader
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"io/ioutil"
 	"os"
 	"path"
@@ -351,6 +354,115 @@ func TestRestrictionNoneInRealLoader(t *testing.T) {
 	}
 }
 
+func TestRestrictionAllowListInRealLoader(t *testing.T) {
+	dir, fSys, err := commonSetupForLoaderRestrictionTest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fSys.Mkdir(filepath.Join(dir, "shared"))
+	fSys.WriteFile(
+		filepath.Join(dir, "shared", "sharedData"), []byte(contentOk))
+	os.Symlink(
+		filepath.Join(dir, "exteriorData"),
+		filepath.Join(dir, "shared", "symLinkToExteriorData"))
+
+	var l ifc.Loader
+
+	l = newLoaderOrDie(
+		RestrictionAllowDirs([]string{filepath.Join(dir, "shared")}), fSys, dir)
+
+	l = doSanityChecksAndDropIntoBase(t, l)
+
+	// Reading from the allowed directory works.
+	data, err := l.Load("../shared/sharedData")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != contentOk {
+		t.Fatalf("unexpected content: %v", data)
+	}
+
+	// Reading symlinks out of the root or
+	// the allowed directory fails.
+	for _, p := range []string{
+		"symLinkToExteriorData",
+		"../shared/symLinkToExteriorData",
+		"../exteriorData",
+	} {
+		_, err = l.Load(p)
+		if err == nil {
+			t.Fatalf("%s: expected error", p)
+		}
+		if !strings.Contains(err.Error(), "or any allowed directory") {
+			t.Fatalf("%s: unexpected err: %v", p, err)
+		}
+	}
+}
+
+func TestLoaderAllow(t *testing.T) {
+	dir, fSys, err := commonSetupForLoaderRestrictionTest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fSys.Mkdir(filepath.Join(dir, "base", "sub"))
+
+	l := newLoaderOrDie(RestrictionAllowDirs(nil), fSys,
+		filepath.Join(dir, "base"))
+	if _, err = l.Load("../exteriorData"); err == nil {
+		t.Fatalf("expected error")
+	}
+	allowed, err := l.Allow([]string{".."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := allowed.Load("../exteriorData")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != contentExteriorData {
+		t.Fatalf("unexpected content: %v", data)
+	}
+
+	// Loaders made from it don't inherit the directories.
+	sub, err := allowed.New("sub")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = sub.Load("../../exteriorData"); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Nor may they allow directories themselves.
+	_, err = sub.Allow([]string{"../.."})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(), "only the kustomization being built") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	// Other restrictors alone decide what's loaded.
+	l = newLoaderOrDie(RestrictionRootOnly, fSys, filepath.Join(dir, "base"))
+	allowed, err = l.Allow([]string{".."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = allowed.Load("../exteriorData")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(), "requires --load_restrictor allowlist") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	l = newLoaderOrDie(RestrictionNone, fSys, dir)
+	if _, err = l.Allow([]string{".."}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func splitOnNthSlash(v string, n int) (string, string) {
 	left := ""
 	for i := 0; i < n; i++ {
@@ -529,6 +641,45 @@ func TestLocalLoaderReferencingGitBase(t *testing.T) {
 	}
 }
 
+func TestLoaderRepo(t *testing.T) {
+	topDir := "/whatever"
+	cloneRoot := topDir + "/someClone"
+	fSys := filesys.MakeFsInMemory()
+	fSys.MkdirAll(cloneRoot + "/foo/base")
+	fSys.MkdirAll(cloneRoot + "/foo/overlay")
+
+	l0 := newLoaderOrDie(RestrictionRootOnly, fSys, topDir)
+	if spec, ref, dir := l0.Repo(); spec != "" || ref != "" || dir != "" {
+		t.Fatalf("unexpected repo %s %s %s", spec, ref, dir)
+	}
+
+	repoSpec, err := git.NewRepoSpecFromUrl(
+		"github.com/someOrg/someRepo/foo/overlay?ref=v1")
+	if err != nil {
+		t.Fatalf("unexpected err: %v\n", err)
+	}
+	l1, err := newLoaderAtGitClone(
+		repoSpec, fSys, nil,
+		git.DoNothingCloner(filesys.ConfirmedDir(cloneRoot)))
+	if err != nil {
+		t.Fatalf("unexpected err: %v\n", err)
+	}
+	spec, ref, dir := l1.Repo()
+	if spec != "https://github.com/someOrg/someRepo.git" ||
+		ref != "v1" || dir != "foo/overlay" {
+		t.Fatalf("unexpected repo %s %s %s", spec, ref, dir)
+	}
+	l2, err := l1.New("../base")
+	if err != nil {
+		t.Fatalf("unexpected err: %v\n", err)
+	}
+	spec, ref, dir = l2.Repo()
+	if spec != "https://github.com/someOrg/someRepo.git" ||
+		ref != "v1" || dir != "foo/base" {
+		t.Fatalf("unexpected repo %s %s %s", spec, ref, dir)
+	}
+}
+
 func TestRepoDirectCycleDetection(t *testing.T) {
 	topDir := "/cycles"
 	cloneRoot := topDir + "/someClone"
@@ -592,3 +743,119 @@ func TestRepoIndirectCycleDetection(t *testing.T) {
 		t.Fatalf("unexpected err: %v", err)
 	}
 }
+
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0644, Size: int64(len(content)),
+			Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	testCases := []struct {
+		path    string
+		archive string
+		dir     string
+		ok      bool
+	}{
+		{"bases/platform-1.4.tgz//overlays/prod",
+			"bases/platform-1.4.tgz", "overlays/prod", true},
+		{"bases/platform.zip", "bases/platform.zip", "", true},
+		{"../base", "", "", false},
+		{"github.com/someOrg/someRepo//base", "", "", false},
+	}
+	for _, tc := range testCases {
+		archive, dir, ok := splitArchivePath(tc.path)
+		if ok != tc.ok || (ok && (archive != tc.archive || dir != tc.dir)) {
+			t.Errorf("%s: unexpected %s, %s, %v", tc.path, archive, dir, ok)
+		}
+	}
+}
+
+func TestLoaderInArchive(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.Mkdir("/app")
+	fSys.WriteFile("/app/secret.txt", []byte("secret"))
+	fSys.WriteFile("/app/bases/platform.tgz", makeTarGz(t, map[string]string{
+		"base/deployment.yaml":             "kind: Deployment\n",
+		"overlays/prod/kustomization.yaml": "",
+		"nested.tar.gz": string(makeTarGz(t, map[string]string{
+			"base/service.yaml": "kind: Service\n",
+		})),
+	}))
+	l := newLoaderOrDie(RestrictionNone, fSys, "/app")
+
+	prod, err := l.New("bases/platform.tgz//overlays/prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prod.Root() != "/app/bases/platform.tgz/overlays/prod" {
+		t.Fatalf("unexpected root %s", prod.Root())
+	}
+
+	// Files outside the root, but in the archive, are
+	// restricted like those in a cloned repo.
+	_, err = prod.Load("../../base/deployment.yaml")
+	if err == nil || !strings.Contains(err.Error(), "is not in or below") {
+		t.Fatalf("expected error, got %v", err)
+	}
+
+	base, err := prod.New("../../base")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := base.Load("deployment.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(c) != "kind: Deployment\n" {
+		t.Fatalf("unexpected content %s", c)
+	}
+
+	// Nothing outside the archive is in reach.
+	_, err = prod.New("../../../..")
+	if err == nil || !strings.Contains(err.Error(), "is not in archive") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	_, err = base.Load("/app/secret.txt")
+	if err == nil || !strings.Contains(err.Error(), "is not in archive") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	_, err = prod.Allow([]string{"../.."})
+	if err == nil || !strings.Contains(err.Error(), "or archives") {
+		t.Fatalf("expected error, got %v", err)
+	}
+
+	// Archives may hold archives.
+	nested, err := prod.New("../../nested.tar.gz//base")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err = nested.Load("service.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(c) != "kind: Service\n" {
+		t.Fatalf("unexpected content %s", c)
+	}
+
+	_, err = l.New("bases/missing.tgz")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	_, err = l.New("bases/platform.tgz//missing")
+	if err == nil || !strings.Contains(err.Error(), "is not in archive") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
@@ -5,9 +5,24 @@
 package loader
 
 import (
+	"net/http"
+	"os"
+	"path/filepath"
+
 	"sigs.k8s.io/kustomize/v3/api/filesys"
 	"sigs.k8s.io/kustomize/v3/api/ifc"
 	"sigs.k8s.io/kustomize/v3/api/internal/git"
+	"sigs.k8s.io/kustomize/v3/api/internal/remotefile"
+)
+
+const (
+	// An environment variable naming the directory
+	// for cached data.  See:
+	// https://specifications.freedesktop.org/basedir-spec/basedir-spec-latest.html
+	xdgCacheHome = "XDG_CACHE_HOME"
+
+	// Use this when xdgCacheHome not defined.
+	defaultCacheSubdir = ".cache"
 )
 
 // NewLoader returns a Loader pointed at the given target.
@@ -22,13 +37,103 @@ func NewLoader(
 	repoSpec, err := git.NewRepoSpecFromUrl(target)
 	if err == nil {
 		// The target qualifies as a remote git target.
-		return newLoaderAtGitClone(
-			repoSpec, fSys, nil, git.ClonerUsingGitExec)
+		ldr, err := newLoaderAtGitClone(
+			repoSpec, fSys, nil, git.DefaultCloner)
+		if err != nil {
+			return nil, err
+		}
+		return ldr, nil
 	}
 	root, err := demandDirectoryRoot(fSys, target)
 	if err != nil {
 		return nil, err
 	}
 	return newLoaderAtConfirmedDir(
-		lr, root, fSys, nil, git.ClonerUsingGitExec), nil
+		lr, root, fSys, nil, git.DefaultCloner), nil
+}
+
+// RemoteOptions says how remote git targets and bases are obtained.
+type RemoteOptions struct {
+	// If Offline is true, remote repositories are only
+	// taken from the cache, and are an error if not
+	// found there.
+	Offline bool
+
+	// If the Locker is non-nil, it's used to pin remote
+	// repositories to commits, and to record their commits.
+	Locker *Locker
+
+	// Mirrors maps repository names to the urls of mirrors
+	// to fetch them from instead; see ValidateFlagGitMirrors.
+	Mirrors map[string]string
+
+	// If SubPathOnly is true, only the path of a remote
+	// base within its repository is checked out, if the
+	// program is built with the gogit tag.  Such a base
+	// can't refer to files outside its path.
+	SubPathOnly bool
+
+	// HttpClient is used to fetch remote files, i.e.
+	// resources and patches given as http(s) urls.
+	// If nil, a client that times out after
+	// two minutes is used.
+	HttpClient *http.Client
+}
+
+// NewCachingLoader is like NewLoader, except that clones of
+// remote git targets and bases are kept in a cache on disk,
+// to be reused by later builds, and shared by all the bases
+// in a build that refer to the same repository and ref.
+// Remote files are kept in the cache too.
+func NewCachingLoader(
+	lr LoadRestrictorFunc,
+	target string, fSys filesys.FileSystem,
+	opts RemoteOptions) (ifc.Loader, error) {
+	cloner := cachingCloner(opts)
+	fetcher := cachingFetcher(opts)
+	repoSpec, err := git.NewRepoSpecFromUrl(target)
+	if err == nil {
+		ldr, err := newLoaderAtGitClone(repoSpec, fSys, nil, cloner)
+		if err != nil {
+			return nil, err
+		}
+		ldr.fetcher = fetcher
+		return ldr, nil
+	}
+	root, err := demandDirectoryRoot(fSys, target)
+	if err != nil {
+		return nil, err
+	}
+	ldr := newLoaderAtConfirmedDir(lr, root, fSys, nil, cloner)
+	ldr.fetcher = fetcher
+	return ldr, nil
+}
+
+// cacheDir returns the directory holding
+// cached data of the given kind.
+func cacheDir(kind string) string {
+	dir := os.Getenv(xdgCacheHome)
+	if len(dir) == 0 {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, defaultCacheSubdir)
+	}
+	return filepath.Join(dir, "kustomize", kind)
+}
+
+func cachingFetcher(opts RemoteOptions) *remotefile.Fetcher {
+	return remotefile.NewFetcher(
+		opts.HttpClient, cacheDir("http"), opts.Offline)
+}
+
+func cachingCloner(opts RemoteOptions) git.Cloner {
+	cache := git.NewCache(cacheDir("git"), opts.Offline, git.DefaultCloner)
+	if opts.SubPathOnly {
+		cache = git.NewSubPathCache(
+			cacheDir("git"), opts.Offline, git.SubPathCloner)
+	}
+	cloner := git.Mirrors(opts.Mirrors).Wrap(cache.Clone)
+	if opts.Locker != nil {
+		cloner = opts.Locker.wrap(cloner)
+	}
+	return cloner
 }
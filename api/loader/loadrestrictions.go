@@ -5,6 +5,7 @@ package loader
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/pflag"
 	"sigs.k8s.io/kustomize/v3/api/filesys"
@@ -17,36 +18,65 @@ const (
 	unknown loadRestrictions = iota
 	rootOnly
 	none
+	allowlist
 )
 
 const (
-	flagName = "load_restrictor"
+	flagName         = "load_restrictor"
+	flagAllowDirName = "allow-dir"
 )
 
 var (
 	flagValue = rootOnly.String()
 	flagHelp  = "if set to '" + none.String() +
 		"', local kustomizations may load files from outside their root. " +
-		"This does, however, break the relocatability of the kustomization."
+		"This does, however, break the relocatability of the kustomization. " +
+		"If set to '" + allowlist.String() + "', they may also load files " +
+		"from the directories given by --" + flagAllowDirName +
+		" and, for the kustomization being built, by its allowDirs field."
+	flagAllowDirValue []string
+	flagAllowDirHelp  = "a directory from which local kustomizations may " +
+		"load files, if --" + flagName + " is '" + allowlist.String() +
+		"'. May be repeated."
 )
 
 func AddFlagLoadRestrictor(set *pflag.FlagSet) {
 	set.StringVar(
 		&flagValue, flagName,
 		rootOnly.String(), flagHelp)
+	set.StringArrayVar(
+		&flagAllowDirValue, flagAllowDirName,
+		nil, flagAllowDirHelp)
 }
 
 func ValidateFlagLoadRestrictor() (LoadRestrictorFunc, error) {
+	if len(flagAllowDirValue) > 0 && flagValue != allowlist.String() {
+		return nil, fmt.Errorf(
+			"flag --%s requires --%s %s",
+			flagAllowDirName, flagName, allowlist.String())
+	}
 	switch flagValue {
 	case rootOnly.String():
 		return RestrictionRootOnly, nil
 	case none.String():
 		return RestrictionNone, nil
+	case allowlist.String():
+		dirs := make([]string, len(flagAllowDirValue))
+		for i, d := range flagAllowDirValue {
+			abs, err := filepath.Abs(d)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"illegal flag value --%s %s; %v",
+					flagAllowDirName, d, err)
+			}
+			dirs[i] = abs
+		}
+		return RestrictionAllowDirs(dirs), nil
 	default:
 		return nil, fmt.Errorf(
 			"illegal flag value --%s %s; legal values: %v",
 			flagName, flagValue,
-			[]string{rootOnly.String(), none.String()})
+			[]string{rootOnly.String(), none.String(), allowlist.String()})
 	}
 }
 
@@ -74,3 +104,61 @@ func RestrictionNone(
 	_ filesys.FileSystem, _ filesys.ConfirmedDir, path string) (string, error) {
 	return path, nil
 }
+
+// RestrictionAllowDirs returns a LoadRestrictorFunc that,
+// like RestrictionRootOnly, permits loading files in or
+// below the root, but also files in or below any of the
+// given absolute directories.  Symlinks are followed before
+// checking, so a link can't escape the allowed directories.
+// The kustomization being built may allow more directories
+// with its allowDirs field; see fileLoader.Allow.
+func RestrictionAllowDirs(dirs []string) LoadRestrictorFunc {
+	dirs = append([]string(nil), dirs...)
+	return func(
+		fSys filesys.FileSystem, root filesys.ConfirmedDir,
+		path string) (string, error) {
+		return restrictToDirs(fSys, root, path, dirs)
+	}
+}
+
+// notAllowedError is the error of a restrictor made by
+// RestrictionAllowDirs, for a file outside the root and
+// the allowed directories.  A loader whose kustomization
+// allows directories checks the file against those too;
+// the errors of other restrictors are final.
+type notAllowedError struct {
+	path string
+	root filesys.ConfirmedDir
+	dirs []string
+}
+
+func (e *notAllowedError) Error() string {
+	return fmt.Sprintf(
+		"security; file '%s' is not in or below '%s' "+
+			"or any allowed directory %v",
+		e.path, e.root, e.dirs)
+}
+
+func restrictToDirs(
+	fSys filesys.FileSystem, root filesys.ConfirmedDir,
+	path string, dirs []string) (string, error) {
+	d, f, err := fSys.CleanedAbs(path)
+	if err != nil {
+		return "", err
+	}
+	if f == "" {
+		return "", fmt.Errorf("'%s' must be a file", path)
+	}
+	if d.HasPrefix(root) {
+		return d.Join(f), nil
+	}
+	for _, dir := range dirs {
+		// Ignore anything that isn't a directory;
+		// it holds no files.
+		allowed, notDir, err := fSys.CleanedAbs(dir)
+		if err == nil && notDir == "" && d.HasPrefix(allowed) {
+			return d.Join(f), nil
+		}
+	}
+	return "", &notAllowedError{path: path, root: root, dirs: dirs}
+}
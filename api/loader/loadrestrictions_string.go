@@ -27,11 +27,12 @@ func _() {
 	_ = x[unknown-0]
 	_ = x[rootOnly-1]
 	_ = x[none-2]
+	_ = x[allowlist-3]
 }
 
-const _loadRestrictions_name = "unknownrootOnlynone"
+const _loadRestrictions_name = "unknownrootOnlynoneallowlist"
 
-var _loadRestrictions_index = [...]uint8{0, 7, 15, 19}
+var _loadRestrictions_index = [...]uint8{0, 7, 15, 19, 28}
 
 func (i loadRestrictions) String() string {
 	if i < 0 || i >= loadRestrictions(len(_loadRestrictions_index)-1) {
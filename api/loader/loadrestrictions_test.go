@@ -72,3 +72,31 @@ func TestRestrictionRootOnly(t *testing.T) {
 		t.Fatalf("unexpected err: %s", err)
 	}
 }
+
+func TestRestrictionAllowDirs(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.Mkdir("/tmp/shared")
+	root := filesys.ConfirmedDir("/tmp/foo")
+	lr := RestrictionAllowDirs([]string{"/tmp/shared", "/tmp/missing"})
+
+	for _, path := range []string{
+		"/tmp/foo/whatever",
+		"/tmp/shared/whatever/beans",
+	} {
+		if _, err := lr(fSys, root, path); err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+	}
+
+	// Illegal.
+	_, err := lr(fSys, root, "/tmp/missing/whatever")
+	if err == nil {
+		t.Fatal("should have an error")
+	}
+	if !strings.Contains(
+		err.Error(),
+		"file '/tmp/missing/whatever' is not in or below '/tmp/foo' "+
+			"or any allowed directory [/tmp/shared /tmp/missing]") {
+		t.Fatalf("unexpected err: %s", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/internal/git"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// LockFileName is the name of the file, beside a kustomization
+// file, that pins the remote bases of a build to commits.
+const LockFileName = "kustomization.lock"
+
+// Locker checks out remote repositories at the commits
+// pinned in a lock, and records the commits of all the
+// remote repositories it checks out, to make a new lock.
+type Locker struct {
+	pins   *types.Lock
+	locked bool
+	// mu guards reached, as repositories
+	// may be checked out concurrently.
+	mu      sync.Mutex
+	reached map[types.LockedRemote]bool
+}
+
+// NewLocker returns a Locker using the given pins, which
+// may be nil.  If locked is true, reaching a remote
+// repository that isn't pinned is an error; otherwise,
+// it's a warning if there are pins at all.
+func NewLocker(pins *types.Lock, locked bool) *Locker {
+	return &Locker{
+		pins:    pins,
+		locked:  locked,
+		reached: make(map[types.LockedRemote]bool),
+	}
+}
+
+// Lock returns a lock pinning all the remote
+// repositories reached so far to their commits.
+func (l *Locker) Lock() *types.Lock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := &types.Lock{}
+	for r := range l.reached {
+		result.Remotes = append(result.Remotes, r)
+	}
+	sort.Slice(result.Remotes, func(i, j int) bool {
+		a, b := result.Remotes[i], result.Remotes[j]
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		return a.Ref < b.Ref
+	})
+	return result
+}
+
+// wrap returns a cloner that clones pinned
+// repositories at their pinned commit.
+func (l *Locker) wrap(cloner git.Cloner) git.Cloner {
+	return func(repoSpec *git.RepoSpec) error {
+		repo, ref := repoSpec.CloneSpec(), repoSpec.Ref
+		commit := l.pins.CommitOf(repo, ref)
+		if commit == "" {
+			if l.locked {
+				return fmt.Errorf(
+					"locked; %s at ref '%s' is not pinned in %s",
+					repo, ref, LockFileName)
+			}
+			if l.pins != nil {
+				log.Printf(
+					"%s at ref '%s' is not pinned in %s; "+
+						"run 'kustomize edit lock' to pin it",
+					repo, ref, LockFileName)
+			}
+		} else {
+			repoSpec.Commit = commit
+		}
+		err := cloner(repoSpec)
+		if err != nil {
+			return err
+		}
+		if repoSpec.Commit != "" {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.reached[types.LockedRemote{
+				Repo: repo, Ref: ref, Commit: repoSpec.Commit}] = true
+		}
+		return nil
+	}
+}
+
+// ReadLock reads the lock file in the given directory,
+// returning nil if there is no such file.
+func ReadLock(fSys filesys.FileSystem, dir string) (*types.Lock, error) {
+	path := filepath.Join(dir, LockFileName)
+	if !fSys.Exists(path) {
+		return nil, nil
+	}
+	content, err := fSys.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lock types.Lock
+	err = yaml.Unmarshal(content, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &lock, nil
+}
+
+// WriteLock writes the lock file in the given directory.
+func WriteLock(fSys filesys.FileSystem, dir string, lock *types.Lock) error {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(filepath.Join(dir, LockFileName), content)
+}
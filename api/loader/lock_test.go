@@ -0,0 +1,103 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/internal/git"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+const (
+	lockTestRepo   = "https://github.com/someOrg/someRepo.git"
+	lockTestCommit = "0123456789abcdef0123456789abcdef01234567"
+)
+
+// fakeCommitCloner records the RepoSpec it was asked
+// to clone, and claims to have cloned the commit.
+func fakeCommitCloner(seen *git.RepoSpec, commit string) git.Cloner {
+	return func(rs *git.RepoSpec) error {
+		*seen = *rs
+		rs.Dir = filesys.ConfirmedDir("/clone")
+		rs.Commit = commit
+		return nil
+	}
+}
+
+func makeLockTestRepoSpec(t *testing.T, ref string) *git.RepoSpec {
+	rs, err := git.NewRepoSpecFromUrl(
+		"github.com/someOrg/someRepo/base?ref=" + ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rs
+}
+
+func TestLockerRecordsCommits(t *testing.T) {
+	var seen git.RepoSpec
+	l := NewLocker(nil, false)
+	err := l.wrap(fakeCommitCloner(&seen, lockTestCommit))(
+		makeLockTestRepoSpec(t, "v1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen.Ref != "v1" || seen.Commit != "" {
+		t.Fatalf("expected clone of v1, got %s at %s", seen.Ref, seen.Commit)
+	}
+	expected := &types.Lock{Remotes: []types.LockedRemote{
+		{Repo: lockTestRepo, Ref: "v1", Commit: lockTestCommit},
+	}}
+	if !reflect.DeepEqual(l.Lock(), expected) {
+		t.Fatalf("expected %v, got %v", expected, l.Lock())
+	}
+}
+
+func TestLockerClonesPinnedCommit(t *testing.T) {
+	var seen git.RepoSpec
+	pins := &types.Lock{Remotes: []types.LockedRemote{
+		{Repo: lockTestRepo, Ref: "v1", Commit: lockTestCommit},
+	}}
+	l := NewLocker(pins, true)
+	rs := makeLockTestRepoSpec(t, "v1")
+	err := l.wrap(fakeCommitCloner(&seen, lockTestCommit))(rs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen.Commit != lockTestCommit {
+		t.Fatalf("expected clone of %s, got %s", lockTestCommit, seen.Commit)
+	}
+	// The ref stays as written, e.g. for origin annotations.
+	if rs.Ref != "v1" {
+		t.Fatalf("expected ref v1, got %s", rs.Ref)
+	}
+	err = l.wrap(fakeCommitCloner(&seen, lockTestCommit))(
+		makeLockTestRepoSpec(t, "v2"))
+	if err == nil {
+		t.Fatalf("expected error for unpinned ref when locked")
+	}
+}
+
+func TestReadWriteLock(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	lock, err := ReadLock(fSys, "/app")
+	if err != nil || lock != nil {
+		t.Fatalf("expected no lock, got %v, %v", lock, err)
+	}
+	expected := &types.Lock{Remotes: []types.LockedRemote{
+		{Repo: lockTestRepo, Ref: "v1", Commit: lockTestCommit},
+	}}
+	if err = WriteLock(fSys, "/app", expected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lock, err = ReadLock(fSys, "/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(lock, expected) {
+		t.Fatalf("expected %v, got %v", expected, lock)
+	}
+}
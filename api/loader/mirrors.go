@@ -0,0 +1,49 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagGitMirrorName = "git-mirror"
+)
+
+var (
+	flagGitMirrorValue []string
+	flagGitMirrorHelp  = "fetch remote bases from a mirror, given as " +
+		"name=url, where name is a repository, e.g. github.com/someOrg/someRepo, " +
+		"or a prefix of repositories, e.g. github.com/someOrg, and url " +
+		"is where to fetch them from instead, e.g. file:///mirrors/someOrg. " +
+		"May be repeated."
+)
+
+func AddFlagGitMirrors(set *pflag.FlagSet) {
+	set.StringArrayVar(
+		&flagGitMirrorValue, flagGitMirrorName,
+		nil, flagGitMirrorHelp)
+}
+
+// ValidateFlagGitMirrors returns the mirrors given
+// by the flag, for use in RemoteOptions.
+func ValidateFlagGitMirrors() (map[string]string, error) {
+	if len(flagGitMirrorValue) == 0 {
+		return nil, nil
+	}
+	mirrors := make(map[string]string, len(flagGitMirrorValue))
+	for _, v := range flagGitMirrorValue {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf(
+				"illegal flag value --%s %s; expected name=url",
+				flagGitMirrorName, v)
+		}
+		mirrors[kv[0]] = kv[1]
+	}
+	return mirrors, nil
+}
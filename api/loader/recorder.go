@@ -0,0 +1,94 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/kustomize/v3/api/ifc"
+)
+
+// Recorder records the paths of the local files that
+// loaders read, and of the local directories at which
+// they make new loaders, e.g. so that a build can be
+// redone when any of them changes.  Files in cloned
+// repositories, and remote files, are not recorded;
+// an archive is recorded in place of its files.
+type Recorder struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{paths: make(map[string]bool)}
+}
+
+func (r *Recorder) record(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[path] = true
+}
+
+// Paths returns the absolute paths recorded, sorted.
+// A recorded file need not exist, e.g. a file that a
+// build tried, but failed, to read.
+func (r *Recorder) Paths() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]string, 0, len(r.paths))
+	for p := range r.paths {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// WithRecorder returns a loader like the given one, which
+// must have been made by this package, that records in the
+// Recorder its root, and the files and directories read by
+// it and by the loaders it makes.
+func WithRecorder(ldr ifc.Loader, r *Recorder) (ifc.Loader, error) {
+	fl, ok := ldr.(*fileLoader)
+	if !ok {
+		return nil, fmt.Errorf("cannot record the reads of loader %T", ldr)
+	}
+	result := *fl
+	result.recorder = r
+	result.recordDir()
+	return &result, nil
+}
+
+// recorderOf returns the Recorder shared by all the
+// loaders in a build, or nil if there's none.
+func (fl *fileLoader) recorderOf() *Recorder {
+	if fl.recorder != nil {
+		return fl.recorder
+	}
+	if fl.referrer != nil {
+		return fl.referrer.recorderOf()
+	}
+	return nil
+}
+
+// recordPath records the path of a local file read by
+// the loader, or of the archive holding it.  Paths
+// in cloned repositories are not recorded.
+func (fl *fileLoader) recordPath(path string) {
+	r := fl.recorderOf()
+	if r == nil || fl.containingRepo() != nil {
+		return
+	}
+	if archive := fl.containingArchive(); archive != nil {
+		path = archive.archive.String()
+	}
+	r.record(path)
+}
+
+// recordDir records the root of the loader.
+func (fl *fileLoader) recordDir() {
+	fl.recordPath(fl.root.String())
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/internal/git"
+)
+
+func TestRecorder(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/overlay/kustomization.yaml", []byte(""))
+	fSys.WriteFile("/app/base/kustomization.yaml", []byte(""))
+	fSys.WriteFile("/app/base/bases.tgz", makeTarGz(t, map[string]string{
+		"platform/kustomization.yaml": "",
+	}))
+	fSys.MkdirAll("/clones/someClone/foo/base")
+	fSys.WriteFile("/clones/someClone/foo/base/kustomization.yaml", []byte(""))
+	root, err := demandDirectoryRoot(fSys, "/app/overlay")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	l := newLoaderAtConfirmedDir(
+		RestrictionRootOnly, root, fSys, nil,
+		git.DoNothingCloner(filesys.ConfirmedDir("/clones/someClone")))
+
+	r := NewRecorder()
+	overlay, err := WithRecorder(l, r)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	overlay.Load("kustomization.yaml")
+	// A file that can't be read is recorded too,
+	// so that a build can be redone once it exists.
+	overlay.Load("missing.yaml")
+	base, err := overlay.New("../base")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	base.Load("kustomization.yaml")
+	platform, err := base.New("bases.tgz//platform")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	platform.Load("kustomization.yaml")
+	remote, err := overlay.New("github.com/someOrg/someRepo/foo/base")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	remote.Load("kustomization.yaml")
+
+	expected := []string{
+		"/app/base",
+		"/app/base/bases.tgz",
+		"/app/base/kustomization.yaml",
+		"/app/overlay",
+		"/app/overlay/kustomization.yaml",
+		"/app/overlay/missing.yaml",
+	}
+	if !reflect.DeepEqual(r.Paths(), expected) {
+		t.Fatalf("expected %v, got %v", expected, r.Paths())
+	}
+
+	// The loader given isn't changed.
+	l.Load("unrecorded.yaml")
+	if len(r.Paths()) != len(expected) {
+		t.Fatalf("unexpected paths %v", r.Paths())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/v3/api/internal/git"
+	"sigs.k8s.io/kustomize/v3/api/internal/remotefile"
+)
+
+// RemoteBase is a local clone of a remote base.
+type RemoteBase struct {
+	// Repo is the clone spec of the repository,
+	// e.g. https://github.com/someOrg/someRepo.git
+	Repo string
+
+	// Ref is the ref as given in the url, if any.
+	Ref string
+
+	// Commit is the commit that was cloned.
+	Commit string
+
+	// Name is the host and path of the repository, without
+	// the protocol, e.g. github.com/someOrg/someRepo.
+	Name string
+
+	// CloneDir is the root of the local clone.
+	CloneDir string
+
+	// Path is the path of the base in the repository.
+	Path string
+}
+
+// IsRemoteBase returns true if the argument, e.g.
+// an entry in the resources field of a kustomization,
+// refers to a base in a remote git repository.
+func IsRemoteBase(url string) bool {
+	if remotefile.IsRemoteFile(url) {
+		return false
+	}
+	_, err := git.NewRepoSpecFromUrl(url)
+	return err == nil
+}
+
+// IsRemoteFile returns true if the argument, e.g.
+// an entry in the resources field of a kustomization,
+// refers to a single file at an http(s) url.  The
+// url may be pinned to the sha256 of the file, as in
+// https://example.com/release.yaml#sha256=9f86...,
+// and must be if it's a plain http url.
+func IsRemoteFile(url string) bool {
+	return remotefile.IsRemoteFile(url)
+}
+
+// RemoteCloner clones remote bases, using
+// the same cache of clones as NewCachingLoader.
+type RemoteCloner struct {
+	cloner git.Cloner
+}
+
+// NewRemoteCloner returns a RemoteCloner.
+func NewRemoteCloner(opts RemoteOptions) *RemoteCloner {
+	return &RemoteCloner{cloner: cachingCloner(opts)}
+}
+
+// Clone clones the remote base at the url.  The clone
+// is held in the cache, and must not be modified.
+func (c *RemoteCloner) Clone(url string) (*RemoteBase, error) {
+	repoSpec, err := git.NewRepoSpecFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	ref := repoSpec.Ref
+	err = c.cloner(repoSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteBase{
+		Repo:     repoSpec.CloneSpec(),
+		Name:     repoSpec.Name(),
+		Ref:      ref,
+		Commit:   repoSpec.Commit,
+		CloneDir: repoSpec.CloneDir().String(),
+		Path:     strings.TrimPrefix(repoSpec.Path, "/"),
+	}, nil
+}
+
+// RemoteFetcher fetches remote files, using the
+// same cache of them as NewCachingLoader.
+type RemoteFetcher struct {
+	fetcher *remotefile.Fetcher
+}
+
+// NewRemoteFetcher returns a RemoteFetcher.
+func NewRemoteFetcher(opts RemoteOptions) *RemoteFetcher {
+	return &RemoteFetcher{fetcher: cachingFetcher(opts)}
+}
+
+// Fetch returns the content of the remote file at the
+// url, failing if the url pins the file to a sha256
+// that the content doesn't match.
+func (f *RemoteFetcher) Fetch(url string) ([]byte, error) {
+	return f.fetcher.Fetch(url)
+}
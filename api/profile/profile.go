@@ -0,0 +1,173 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package profile records the wall time and memory
+// allocations of the stages of a build, for finding
+// what makes a build slow.
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+)
+
+// Stage records one stage of a build, e.g. the
+// accumulation of a kustomization, or one run of
+// a generator or transformer.  The time and the
+// allocations of a stage include those of the stages
+// run within it, e.g. the accumulation of a
+// kustomization includes that of its bases.  The
+// allocations are those of the whole process, so
+// they include those of the bases accumulated
+// concurrently with the stage.
+type Stage struct {
+	// Root is the root of the kustomization
+	// that ran the stage.
+	Root  string `json:"root"`
+	Stage string `json:"stage"`
+	// Wall is the wall time of the stage, in
+	// nanoseconds when encoded as JSON.
+	Wall time.Duration `json:"wall"`
+	// Allocs is the number of heap objects allocated,
+	// and AllocBytes the number of bytes allocated.
+	Allocs     uint64 `json:"allocs"`
+	AllocBytes uint64 `json:"allocBytes"`
+}
+
+// Profile is the set of stages run in a build.
+// A nil Profile records nothing.
+type Profile struct {
+	mu     sync.Mutex
+	Stages []Stage `json:"stages"`
+}
+
+// New returns an empty profile.
+func New() *Profile {
+	return &Profile{Stages: []Stage{}}
+}
+
+// Time runs f, recording it as a stage with the given
+// root and name, and returns what f returns.
+func (p *Profile) Time(root, name string, f func() error) error {
+	if p == nil {
+		return f()
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	err := f()
+	wall := time.Since(start)
+	runtime.ReadMemStats(&after)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Stages = append(p.Stages, Stage{
+		Root:       root,
+		Stage:      name,
+		Wall:       wall,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	})
+	return err
+}
+
+// Wrap returns a transformer that runs the given
+// transformer as a stage with the given root and name.
+// If the profile is nil, the transformer is
+// returned unchanged.
+func (p *Profile) Wrap(
+	root, name string, t resmap.Transformer) resmap.Transformer {
+	if p == nil {
+		return t
+	}
+	return &profilingTransformer{
+		profile: p, root: root, name: name, delegate: t}
+}
+
+type profilingTransformer struct {
+	profile  *Profile
+	root     string
+	name     string
+	delegate resmap.Transformer
+}
+
+func (pt *profilingTransformer) Transform(m resmap.ResMap) error {
+	return pt.profile.Time(pt.root, pt.name, func() error {
+		return pt.delegate.Transform(m)
+	})
+}
+
+// WrapGenerator returns a generator that runs the given
+// generator as a stage with the given root and name.
+// If the profile is nil, the generator is
+// returned unchanged.
+func (p *Profile) WrapGenerator(
+	root, name string, g resmap.Generator) resmap.Generator {
+	if p == nil {
+		return g
+	}
+	return &profilingGenerator{
+		profile: p, root: root, name: name, delegate: g}
+}
+
+type profilingGenerator struct {
+	profile  *Profile
+	root     string
+	name     string
+	delegate resmap.Generator
+}
+
+func (pg *profilingGenerator) Generate() (m resmap.ResMap, err error) {
+	err = pg.profile.Time(pg.root, pg.name, func() error {
+		m, err = pg.delegate.Generate()
+		return err
+	})
+	return m, err
+}
+
+// Sorted returns the stages, the slowest first.
+func (p *Profile) Sorted() []Stage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]Stage, len(p.Stages))
+	copy(result, p.Stages)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Wall > result[j].Wall
+	})
+	return result
+}
+
+// AsJson returns the stages, the slowest
+// first, as indented JSON.
+func (p *Profile) AsJson() ([]byte, error) {
+	out, err := json.MarshalIndent(
+		struct {
+			Stages []Stage `json:"stages"`
+		}{Stages: p.Sorted()}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Report returns the stages, the slowest
+// first, as a human-readable table.
+func (p *Profile) Report() []byte {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "WALL\tALLOCS\tBYTES\t  STAGE")
+	for _, s := range p.Sorted() {
+		fmt.Fprintf(w, "%s\t%d\t%d\t  %s in %s\n",
+			s.Wall.Round(time.Microsecond), s.Allocs, s.AllocBytes,
+			s.Stage, s.Root)
+	}
+	w.Flush()
+	return b.Bytes()
+}
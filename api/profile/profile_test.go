@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package profile_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "sigs.k8s.io/kustomize/v3/api/profile"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+)
+
+type transformerFunc func(m resmap.ResMap) error
+
+func (f transformerFunc) Transform(m resmap.ResMap) error {
+	return f(m)
+}
+
+type generatorFunc func() (resmap.ResMap, error)
+
+func (f generatorFunc) Generate() (resmap.ResMap, error) {
+	return f()
+}
+
+func TestNilProfileDoesNotWrap(t *testing.T) {
+	var p *Profile
+	f := transformerFunc(func(m resmap.ResMap) error { return nil })
+	if _, ok := p.Wrap("/app", "f", f).(transformerFunc); !ok {
+		t.Fatalf("expected unwrapped transformer")
+	}
+	g := generatorFunc(func() (resmap.ResMap, error) { return nil, nil })
+	if _, ok := p.WrapGenerator("/app", "g", g).(generatorFunc); !ok {
+		t.Fatalf("expected unwrapped generator")
+	}
+	ran := false
+	err := p.Time("/app", "s", func() error { ran = true; return nil })
+	if err != nil || !ran {
+		t.Fatalf("expected stage to run without error")
+	}
+}
+
+func TestProfileRecordsStages(t *testing.T) {
+	p := New()
+	var keep [][]byte
+	err := p.Wrap("/app", "slow", transformerFunc(
+		func(m resmap.ResMap) error {
+			for i := 0; i < 10; i++ {
+				keep = append(keep, make([]byte, 1024))
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		})).Transform(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fail := errors.New("fail")
+	_, err = p.WrapGenerator("/app/base", "fast", generatorFunc(
+		func() (resmap.ResMap, error) {
+			return nil, fail
+		})).Generate()
+	if err != fail {
+		t.Fatalf("expected generator's error, got %v", err)
+	}
+	if len(keep) != 10 {
+		t.Fatalf("expected allocations to be kept")
+	}
+
+	stages := p.Sorted()
+	if len(stages) != 2 {
+		t.Fatalf("expected 2 stages, got %v", stages)
+	}
+	slow, fast := stages[0], stages[1]
+	if slow.Stage != "slow" || slow.Root != "/app" ||
+		fast.Stage != "fast" || fast.Root != "/app/base" {
+		t.Fatalf("unexpected stages %v", stages)
+	}
+	if slow.Wall < 20*time.Millisecond || slow.Wall <= fast.Wall {
+		t.Fatalf("unexpected wall times %v", stages)
+	}
+	if slow.Allocs < 10 || slow.AllocBytes < 10*1024 {
+		t.Fatalf("unexpected allocations %v", slow)
+	}
+
+	report := string(p.Report())
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	if len(lines) != 3 ||
+		!strings.HasSuffix(lines[0], "BYTES  STAGE") ||
+		!strings.HasSuffix(lines[1], "slow in /app") ||
+		!strings.HasSuffix(lines[2], "fast in /app/base") {
+		t.Fatalf("unexpected report\n%s", report)
+	}
+
+	out, err := p.AsJson()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded struct {
+		Stages []Stage `json:"stages"`
+	}
+	err = json.Unmarshal(out, &decoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(decoded.Stages) != 2 || decoded.Stages[0] != slow {
+		t.Fatalf("unexpected json\n%s", out)
+	}
+}
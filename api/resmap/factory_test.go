@@ -103,7 +103,7 @@ metadata:
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(m, expected) {
+	if !reflect.DeepEqual(m.Resources(), expected.Resources()) {
 		t.Fatalf("%#v doesn't match expected %#v", m, expected)
 	}
 }
@@ -0,0 +1,296 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resmap
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+)
+
+// resIndex indexes the resources of a resWrangler by
+// their current and original ids, and by the Gvk and
+// namespace of their current ids, so that finding a
+// resource by any of these doesn't mean scanning them all.
+//
+// A resource's ids can change while it's indexed, e.g.
+// when a transformer sets its namespace by writing to
+// its map, and nothing tells the index.  But to change
+// a resource, a caller must have got it from the
+// resWrangler, or have given it to it, so the index
+// follows which resources have been handed out.  Handing
+// out a few, e.g. the result of a lookup, or appending
+// one, queues their entries to be checked; handing out
+// all of them, e.g. from Resources, marks the whole
+// index stale.  Before each lookup the index catches up,
+// checking the queued entries, or every entry if it's
+// stale, and indexing again those whose ids changed.
+// So a lookup sees every change made to the resources
+// handed out before it, including one that gives a
+// resource the id of another, and a lookup that finds
+// nothing can be trusted without scanning.  A change
+// made to a resource after a lookup that followed its
+// handing out may be missed, though a lookup still
+// checks each entry it finds.
+type resIndex struct {
+	entries     map[*resource.Resource]*indexEntry
+	byCurId     map[resid.ResId][]*indexEntry
+	byOrgId     map[resid.ResId][]*indexEntry
+	byGvk       map[resid.Gvk][]*indexEntry
+	byNamespace map[string][]*indexEntry
+	// pending holds the entries of resources handed
+	// out since the last lookup.
+	pending []*indexEntry
+	// stale is true if every resource may have been
+	// handed out since the last lookup.
+	stale bool
+}
+
+// indexEntry is a resource and the keys it's indexed by.
+// Every slice of entries in the index is sorted by pos.
+type indexEntry struct {
+	res *resource.Resource
+	// pos is the index of res in the resWrangler's rList.
+	pos int
+	// cur and org are the resource's ids, as last
+	// indexed, with their effective namespaces, since
+	// ResId.Equals compares those.  The Gvk and namespace
+	// keys are those of cur.
+	cur resid.ResId
+	org resid.ResId
+}
+
+func newResIndex() *resIndex {
+	return &resIndex{
+		entries:     make(map[*resource.Resource]*indexEntry),
+		byCurId:     make(map[resid.ResId][]*indexEntry),
+		byOrgId:     make(map[resid.ResId][]*indexEntry),
+		byGvk:       make(map[resid.Gvk][]*indexEntry),
+		byNamespace: make(map[string][]*indexEntry),
+	}
+}
+
+// indexKey returns the key indexing resources whose
+// ids equal the given id, per ResId.Equals.
+func indexKey(id resid.ResId) resid.ResId {
+	id.Namespace = id.EffectiveNamespace()
+	return id
+}
+
+// add indexes a resource at the given position in rList,
+// which must be after that of every indexed resource,
+// or else at the position of one just removed.
+func (x *resIndex) add(res *resource.Resource, pos int) {
+	e := &indexEntry{
+		res: res,
+		pos: pos,
+		cur: indexKey(res.CurId()),
+		org: indexKey(res.OrgId()),
+	}
+	x.entries[res] = e
+	x.insert(e)
+}
+
+// remove removes a resource from the index.
+func (x *resIndex) remove(res *resource.Resource) {
+	e, ok := x.entries[res]
+	if !ok {
+		return
+	}
+	x.delete(e)
+	delete(x.entries, res)
+}
+
+func (x *resIndex) insert(e *indexEntry) {
+	x.byCurId[e.cur] = insertEntry(x.byCurId[e.cur], e)
+	x.byOrgId[e.org] = insertEntry(x.byOrgId[e.org], e)
+	x.byGvk[e.cur.Gvk] = insertEntry(x.byGvk[e.cur.Gvk], e)
+	x.byNamespace[e.cur.Namespace] = insertEntry(
+		x.byNamespace[e.cur.Namespace], e)
+}
+
+func (x *resIndex) delete(e *indexEntry) {
+	if s := deleteEntry(x.byCurId[e.cur], e); len(s) > 0 {
+		x.byCurId[e.cur] = s
+	} else {
+		delete(x.byCurId, e.cur)
+	}
+	if s := deleteEntry(x.byOrgId[e.org], e); len(s) > 0 {
+		x.byOrgId[e.org] = s
+	} else {
+		delete(x.byOrgId, e.org)
+	}
+	if s := deleteEntry(x.byGvk[e.cur.Gvk], e); len(s) > 0 {
+		x.byGvk[e.cur.Gvk] = s
+	} else {
+		delete(x.byGvk, e.cur.Gvk)
+	}
+	if s := deleteEntry(x.byNamespace[e.cur.Namespace], e); len(s) > 0 {
+		x.byNamespace[e.cur.Namespace] = s
+	} else {
+		delete(x.byNamespace, e.cur.Namespace)
+	}
+}
+
+// insertEntry inserts an entry into a slice sorted by pos.
+func insertEntry(s []*indexEntry, e *indexEntry) []*indexEntry {
+	i := sort.Search(len(s), func(i int) bool { return s[i].pos >= e.pos })
+	s = append(s, nil)
+	copy(s[i+1:], s[i:])
+	s[i] = e
+	return s
+}
+
+// deleteEntry deletes an entry from a slice sorted by pos.
+func deleteEntry(s []*indexEntry, e *indexEntry) []*indexEntry {
+	i := sort.Search(len(s), func(i int) bool { return s[i].pos >= e.pos })
+	if i == len(s) || s[i] != e {
+		return s
+	}
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = nil
+	return s[:len(s)-1]
+}
+
+// removed updates the positions of the resources in
+// rList, which has just had an element removed at pos.
+// Since the positions keep their order, no slice of
+// entries needs sorting again.
+func (x *resIndex) removed(rList []*resource.Resource, pos int) {
+	for _, r := range rList[pos:] {
+		x.entries[r].pos--
+	}
+}
+
+// changed returns true if the entry's resource's ids
+// changed since it was indexed.
+func (e *indexEntry) changed() bool {
+	return indexKey(e.res.CurId()) != e.cur ||
+		indexKey(e.res.OrgId()) != e.org
+}
+
+// reindex indexes an entry again.
+func (x *resIndex) reindex(e *indexEntry) {
+	x.delete(e)
+	e.cur = indexKey(e.res.CurId())
+	e.org = indexKey(e.res.OrgId())
+	x.insert(e)
+}
+
+// handOut notes that the given resources are being
+// handed out, and so may be changed.
+func (x *resIndex) handOut(rs ...*resource.Resource) {
+	if x.stale {
+		return
+	}
+	for _, r := range rs {
+		if e, ok := x.entries[r]; ok {
+			x.pending = append(x.pending, e)
+		}
+	}
+	if len(x.pending) > len(x.entries) {
+		x.handOutAll()
+	}
+}
+
+// handOutAll notes that every resource is
+// being handed out, and so may be changed.
+func (x *resIndex) handOutAll() {
+	x.stale = true
+	x.pending = nil
+}
+
+// catchUp indexes again the entries of the resources
+// handed out since the last lookup whose ids changed.
+func (x *resIndex) catchUp() {
+	if x.stale {
+		for _, e := range x.entries {
+			if e.changed() {
+				x.reindex(e)
+			}
+		}
+		x.stale = false
+		return
+	}
+	for _, e := range x.pending {
+		// The entry's resource may have been removed.
+		if x.entries[e.res] == e && e.changed() {
+			x.reindex(e)
+		}
+	}
+	x.pending = nil
+}
+
+// check indexes again those of the given entries,
+// found by a lookup, whose resources' ids changed.
+func (x *resIndex) check(s []*indexEntry) {
+	var changed []*indexEntry
+	for _, e := range s {
+		if e.changed() {
+			changed = append(changed, e)
+		}
+	}
+	for _, e := range changed {
+		x.reindex(e)
+	}
+}
+
+// withCurId returns the entries whose current ids
+// equal the given id, in the order of rList.
+func (x *resIndex) withCurId(id resid.ResId) []*indexEntry {
+	x.catchUp()
+	key := indexKey(id)
+	x.check(x.byCurId[key])
+	return x.byCurId[key]
+}
+
+// withOrgId returns the entries whose original ids
+// equal the given id, in the order of rList.
+func (x *resIndex) withOrgId(id resid.ResId) []*indexEntry {
+	x.catchUp()
+	key := indexKey(id)
+	x.check(x.byOrgId[key])
+	return x.byOrgId[key]
+}
+
+// withGvk returns the entries whose current ids
+// have the given Gvk, in the order of rList.
+func (x *resIndex) withGvk(gvk resid.Gvk) []*indexEntry {
+	x.catchUp()
+	x.check(x.byGvk[gvk])
+	return x.byGvk[gvk]
+}
+
+// withNamespace returns the entries whose current ids
+// have the given effective namespace, in the order
+// of rList.
+func (x *resIndex) withNamespace(ns string) []*indexEntry {
+	x.catchUp()
+	x.check(x.byNamespace[ns])
+	return x.byNamespace[ns]
+}
+
+// groupedByNamespace returns the resources grouped by
+// the effective namespaces of their current ids.
+func (x *resIndex) groupedByNamespace() map[string][]*resource.Resource {
+	x.catchUp()
+	result := make(map[string][]*resource.Resource, len(x.byNamespace))
+	for ns, s := range x.byNamespace {
+		result[ns] = resourcesOf(s)
+	}
+	return result
+}
+
+// resourcesOf returns the resources of the given entries.
+func resourcesOf(s []*indexEntry) []*resource.Resource {
+	if len(s) == 0 {
+		return nil
+	}
+	result := make([]*resource.Resource, len(s))
+	for i, e := range s {
+		result[i] = e.res
+	}
+	return result
+}
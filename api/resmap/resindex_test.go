@@ -0,0 +1,320 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	. "sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+var cmGvk = resid.Gvk{Version: "v1", Kind: "ConfigMap"}
+
+func cmId(name, ns string) resid.ResId {
+	return resid.NewResIdWithNamespace(cmGvk, name, ns)
+}
+
+func expectOne(
+	t *testing.T, m ResMap, id resid.ResId, expected *resource.Resource) {
+	t.Helper()
+	r, err := m.GetByCurrentId(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r != expected {
+		t.Fatalf("found %s for %s", r.CurId(), id)
+	}
+}
+
+func expectNone(t *testing.T, m ResMap, id resid.ResId) {
+	t.Helper()
+	if r := m.GetAllByCurrentId(id); len(r) != 0 {
+		t.Fatalf("unexpected match for %s: %v", id, r)
+	}
+}
+
+func TestIndexFollowsNameChanges(t *testing.T) {
+	m := New()
+	r1, r2 := makeCm(1), makeCm(2)
+	doAppend(t, m, r1)
+	doAppend(t, m, r2)
+
+	r1.SetName("renamed")
+	expectNone(t, m, cmId("cm001", ""))
+	expectOne(t, m, cmId("renamed", ""), r1)
+	if _, err := m.GetByOriginalId(cmId("cm001", "")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Append(makeCm(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Append(rf.FromMap(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "renamed"},
+	})); err == nil {
+		t.Fatalf("expected an error appending a duplicate of a renamed resource")
+	}
+
+	// Written through the map, as transformers do.
+	meta := r2.Map()["metadata"].(map[string]interface{})
+	meta["namespace"] = "ns"
+	expectNone(t, m, cmId("cm002", ""))
+	expectOne(t, m, cmId("cm002", "ns"), r2)
+	if g := m.GroupedByCurrentNamespace(); len(g["ns"]) != 1 || g["ns"][0] != r2 {
+		t.Fatalf("unexpected grouping: %v", g)
+	}
+}
+
+func TestIndexFollowsManyChanges(t *testing.T) {
+	m := New()
+	for i := 0; i < 100; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	// Many changes, of which the map isn't told,
+	// as a transformer makes them.
+	rs := m.Resources()
+	for i := 0; i < 3000; i++ {
+		rs[i%100].SetNamespace(fmt.Sprintf("ns%d", i))
+	}
+	for i := 0; i < 100; i++ {
+		expectOne(t, m, cmId(fmt.Sprintf("cm%03d", i),
+			fmt.Sprintf("ns%d", 2900+i)), rs[i])
+	}
+}
+
+func TestIndexSeesIdConflicts(t *testing.T) {
+	m := New()
+	doAppend(t, m, makeCm(1))
+	prod := makeCm(2)
+	prod.SetNamespace("prod")
+	doAppend(t, m, prod)
+	doAppend(t, m, makeCm(3))
+	expectOne(t, m, cmId("cm002", "prod"), prod)
+
+	// Moved to the id of another, as a transformer moves
+	// them, without looking up its old id.
+	for _, r := range m.Resources() {
+		r.SetName("cm002")
+		r.SetNamespace("prod")
+	}
+	if r := m.GetAllByCurrentId(cmId("cm002", "prod")); len(r) != 3 {
+		t.Fatalf("expected three matches, got %v", r)
+	}
+
+	m = New()
+	doAppend(t, m, makeCm(1))
+	doAppend(t, m, makeCm(2))
+	m.GetByIndex(0).SetName("other")
+	if err := m.Append(rf.FromMap(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "other"},
+	})); err == nil {
+		t.Fatalf("expected an error appending a duplicate of a renamed resource")
+	}
+}
+
+func TestIndexByGvkAndNamespace(t *testing.T) {
+	m := New()
+	for i := 0; i < 4; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	for _, r := range m.Resources()[1:3] {
+		r.SetGvk(resid.Gvk{Version: "v1", Kind: "Namespace"})
+	}
+	if r := m.GetAllByGvk(cmGvk); len(r) != 2 ||
+		r[0].GetName() != "cm000" || r[1].GetName() != "cm003" {
+		t.Fatalf("unexpected ConfigMaps: %v", r)
+	}
+	if r := m.NonNamespaceable(); len(r) != 2 ||
+		r[0].GetName() != "cm001" || r[1].GetName() != "cm002" {
+		t.Fatalf("unexpected non-namespaceable resources: %v", r)
+	}
+	g := m.GroupedByCurrentNamespace()
+	if len(g) != 1 || len(g[resid.DefaultNamespace]) != 2 {
+		t.Fatalf("unexpected grouping: %v", g)
+	}
+}
+
+func TestIndexKeepsOrder(t *testing.T) {
+	m := New()
+	for i := 0; i < 10; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	doRemove(t, m, cmId("cm003", ""))
+	doRemove(t, m, cmId("cm007", ""))
+	i, err := m.GetIndexOfCurrentId(cmId("cm008", ""))
+	if err != nil || i != 6 {
+		t.Fatalf("unexpected index %d, error %v", i, err)
+	}
+	r := makeCm(5)
+	r.SetNamespace("ns")
+	m.GetByIndex(0).SetNamespace("ns")
+	m.GetByIndex(7).SetNamespace("ns")
+	if _, err := m.Replace(r); err == nil {
+		t.Fatalf("expected an error replacing a resource that isn't there")
+	}
+	r.SetNamespace("")
+	if i, err := m.Replace(r); err != nil || i != 4 {
+		t.Fatalf("unexpected index %d, error %v", i, err)
+	}
+	r.SetNamespace("ns")
+	var names []string
+	for _, r := range m.GroupedByCurrentNamespace()["ns"] {
+		names = append(names, r.GetName())
+	}
+	if fmt.Sprint(names) != "[cm000 cm005 cm009]" {
+		t.Fatalf("unexpected order: %v", names)
+	}
+}
+
+func TestIndexOfCopies(t *testing.T) {
+	m := New()
+	for i := 0; i < 3; i++ {
+		doAppend(t, m, makeCm(i))
+	}
+	c := m.DeepCopy()
+	c.GetByIndex(1).SetName("renamed")
+	expectOne(t, m, cmId("cm001", ""), m.GetByIndex(1))
+	expectOne(t, c, cmId("renamed", ""), c.GetByIndex(1))
+	expectNone(t, c, cmId("cm001", ""))
+
+	generated := rf.FromMapAndOption(
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "renamed"},
+		}, &types.GeneratorArgs{Behavior: "replace"}, nil)
+	if err := c.AbsorbAll(rmF.FromResource(generated)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOne(t, c, cmId("renamed", ""), generated)
+	if c.GetByIndex(1) != generated {
+		t.Fatalf("expected the replacement in place")
+	}
+}
+
+// makeResMap returns a ResMap of n resources, of
+// several kinds, in several namespaces.
+func makeResMap(b *testing.B, n int) ResMap {
+	kinds := []string{"ConfigMap", "Secret", "Service", "Deployment"}
+	m := New()
+	for i := 0; i < n; i++ {
+		err := m.Append(rf.FromMap(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kinds[i%len(kinds)],
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("r%d", i),
+				"namespace": fmt.Sprintf("ns%d", i%10),
+			},
+		}))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return m
+}
+
+const benchmarkResources = 10000
+
+func BenchmarkAppend(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		makeResMap(b, benchmarkResources)
+	}
+}
+
+func BenchmarkGetAllByCurrentId(b *testing.B) {
+	m := makeResMap(b, benchmarkResources)
+	ids := m.AllIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(m.GetAllByCurrentId(ids[i%len(ids)])) != 1 {
+			b.Fatal("no match")
+		}
+	}
+}
+
+// BenchmarkGetMatchingResourcesByCurrentId is the above
+// without the index, for comparison.
+func BenchmarkGetMatchingResourcesByCurrentId(b *testing.B) {
+	m := makeResMap(b, benchmarkResources)
+	ids := m.AllIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(m.GetMatchingResourcesByCurrentId(ids[i%len(ids)].Equals)) != 1 {
+			b.Fatal("no match")
+		}
+	}
+}
+
+func BenchmarkGetAllByGvk(b *testing.B) {
+	m := makeResMap(b, benchmarkResources)
+	gvk := resid.Gvk{Version: "v1", Kind: "ConfigMap"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(m.GetAllByGvk(gvk)) != benchmarkResources/4 {
+			b.Fatal("unexpected matches")
+		}
+	}
+}
+
+func BenchmarkGetByOriginalId(b *testing.B) {
+	m := makeResMap(b, benchmarkResources)
+	ids := m.AllIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.GetByOriginalId(ids[i%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetByOriginalIdAfterRename renames a
+// resource before each lookup, so the index must
+// catch up with the change.
+func BenchmarkGetByOriginalIdAfterRename(b *testing.B) {
+	m := makeResMap(b, benchmarkResources)
+	ids := m.AllIds()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetByIndex(i % len(ids)).SetName(fmt.Sprintf("renamed%d", i))
+		if _, err := m.GetByOriginalId(ids[i%len(ids)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAbsorbAll(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := makeResMap(b, benchmarkResources)
+		other := makeResMap(b, benchmarkResources)
+		for j, r := range other.Resources() {
+			r.SetName(fmt.Sprintf("other%d", j))
+		}
+		b.StartTimer()
+		if err := m.AbsorbAll(other); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRemove(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := makeResMap(b, benchmarkResources)
+		ids := m.AllIds()
+		b.StartTimer()
+		for j := 0; j < 1000; j++ {
+			if err := m.Remove(ids[j*7]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ package resmap
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"regexp"
 
@@ -85,6 +86,11 @@ type TransformerPlugin interface {
 // resource to transform, try the OrgId first, and if this
 // fails or finds too many, it might make sense to then try
 // the CurrId.  Depends on the situation.
+//
+// A resource's ids may be changed in place, as long as
+// the resource was got from the ResMap, e.g. with
+// Resources, after the ResMap's last lookup by id.
+// Lookups made after the change see it.
 type ResMap interface {
 	// Size reports the number of resources.
 	Size() int
@@ -133,6 +139,18 @@ type ResMap interface {
 	// AsYaml returns the yaml form of resources.
 	AsYaml() ([]byte, error)
 
+	// AsJson returns the resources as a single
+	// JSON array.
+	AsJson() ([]byte, error)
+
+	// AsJsonLines returns the resources as JSON
+	// objects, one per line.
+	AsJsonLines() ([]byte, error)
+
+	// AsList returns the yaml form of a single
+	// v1/List object holding the resources as items.
+	AsList() ([]byte, error)
+
 	// GetByIndex returns a resource at the given index,
 	// nil if out of range.
 	GetByIndex(int) *resource.Resource
@@ -151,6 +169,22 @@ type ResMap interface {
 	// who's OriginalId is matched by the argument.
 	GetMatchingResourcesByOriginalId(matches IdMatcher) []*resource.Resource
 
+	// GetAllByCurrentId returns the resources who's CurId
+	// equals the argument.  Unlike calling
+	// GetMatchingResourcesByCurrentId with id.Equals,
+	// it doesn't examine every resource.
+	GetAllByCurrentId(resid.ResId) []*resource.Resource
+
+	// GetAllByOriginalId returns the resources who's
+	// OriginalId equals the argument.  Unlike calling
+	// GetMatchingResourcesByOriginalId with id.Equals,
+	// it doesn't examine every resource.
+	GetAllByOriginalId(resid.ResId) []*resource.Resource
+
+	// GetAllByGvk returns the resources who's CurId
+	// has the given Gvk.
+	GetAllByGvk(resid.Gvk) []*resource.Resource
+
 	// GetByCurrentId is shorthand for calling
 	// GetMatchingResourcesByCurrentId with a matcher requiring
 	// an exact match, returning an error on multiple or no matches.
@@ -252,6 +286,10 @@ type resWrangler struct {
 	// specify in kustomizations to be maintained and
 	// available as an option for final YAML rendering.
 	rList []*resource.Resource
+
+	// index finds the resources with a given id,
+	// Gvk or namespace without scanning rList.
+	index *resIndex
 }
 
 func newOne() *resWrangler {
@@ -263,6 +301,7 @@ func newOne() *resWrangler {
 // Clear implements ResMap.
 func (m *resWrangler) Clear() {
 	m.rList = nil
+	m.index = newResIndex()
 }
 
 // Size implements ResMap.
@@ -271,10 +310,8 @@ func (m *resWrangler) Size() int {
 }
 
 func (m *resWrangler) indexOfResource(other *resource.Resource) int {
-	for i, r := range m.rList {
-		if r == other {
-			return i
-		}
+	if e, ok := m.index.entries[other]; ok {
+		return e.pos
 	}
 	return -1
 }
@@ -283,32 +320,43 @@ func (m *resWrangler) indexOfResource(other *resource.Resource) int {
 func (m *resWrangler) Resources() []*resource.Resource {
 	tmp := make([]*resource.Resource, len(m.rList))
 	copy(tmp, m.rList)
+	m.index.handOutAll()
 	return tmp
 }
 
 // Append implements ResMap.
 func (m *resWrangler) Append(res *resource.Resource) error {
 	id := res.CurId()
-	if r := m.GetMatchingResourcesByCurrentId(id.Equals); len(r) > 0 {
+	if len(m.index.withCurId(id)) > 0 {
 		return fmt.Errorf(
 			"may not add resource with an already registered id: %s", id)
 	}
-	m.rList = append(m.rList, res)
+	m.append(res)
+	m.index.handOut(res)
 	return nil
 }
 
 // Remove implements ResMap.
 func (m *resWrangler) Remove(adios resid.ResId) error {
-	tmp := newOne()
-	for _, r := range m.rList {
-		if r.CurId() != adios {
-			tmp.Append(r)
+	var found *indexEntry
+	for _, e := range m.index.withCurId(adios) {
+		if e.res.CurId() != adios {
+			continue
 		}
+		if found != nil {
+			return fmt.Errorf("id %s not found in removal", adios)
+		}
+		found = e
 	}
-	if tmp.Size() != m.Size()-1 {
+	if found == nil {
 		return fmt.Errorf("id %s not found in removal", adios)
 	}
-	m.rList = tmp.rList
+	i := found.pos
+	m.index.remove(found.res)
+	copy(m.rList[i:], m.rList[i+1:])
+	m.rList[len(m.rList)-1] = nil
+	m.rList = m.rList[:len(m.rList)-1]
+	m.index.removed(m.rList, i)
 	return nil
 }
 
@@ -322,7 +370,10 @@ func (m *resWrangler) Replace(res *resource.Resource) (int, error) {
 	if i < 0 {
 		return -1, fmt.Errorf("cannot find resource with id %s to replace", id)
 	}
+	m.index.remove(m.rList[i])
 	m.rList[i] = res
+	m.index.add(res, i)
+	m.index.handOut(res)
 	return i, nil
 }
 
@@ -361,23 +412,20 @@ func (m *resWrangler) GetByIndex(i int) *resource.Resource {
 	if i < 0 || i >= m.Size() {
 		return nil
 	}
+	m.index.handOut(m.rList[i])
 	return m.rList[i]
 }
 
 // GetIndexOfCurrentId implements ResMap.
 func (m *resWrangler) GetIndexOfCurrentId(id resid.ResId) (int, error) {
-	count := 0
-	result := -1
-	for i, r := range m.rList {
-		if id.Equals(r.CurId()) {
-			count++
-			result = i
-		}
+	matches := m.index.withCurId(id)
+	if len(matches) > 1 {
+		return -1, fmt.Errorf("id matched %d resources", len(matches))
 	}
-	if count > 1 {
-		return -1, fmt.Errorf("id matched %d resources", count)
+	if len(matches) == 0 {
+		return -1, nil
 	}
-	return result, nil
+	return matches[0].pos, nil
 }
 
 type IdFromResource func(r *resource.Resource) resid.ResId
@@ -397,6 +445,29 @@ func (m *resWrangler) GetMatchingResourcesByOriginalId(
 	return m.filteredById(matches, GetOriginalId)
 }
 
+// GetAllByCurrentId implements ResMap.
+func (m *resWrangler) GetAllByCurrentId(
+	id resid.ResId) []*resource.Resource {
+	result := resourcesOf(m.index.withCurId(id))
+	m.index.handOut(result...)
+	return result
+}
+
+// GetAllByOriginalId implements ResMap.
+func (m *resWrangler) GetAllByOriginalId(
+	id resid.ResId) []*resource.Resource {
+	result := resourcesOf(m.index.withOrgId(id))
+	m.index.handOut(result...)
+	return result
+}
+
+// GetAllByGvk implements ResMap.
+func (m *resWrangler) GetAllByGvk(gvk resid.Gvk) []*resource.Resource {
+	result := resourcesOf(m.index.withGvk(gvk))
+	m.index.handOut(result...)
+	return result
+}
+
 func (m *resWrangler) filteredById(
 	matches IdMatcher, idGetter IdFromResource) []*resource.Resource {
 	var result []*resource.Resource
@@ -405,19 +476,20 @@ func (m *resWrangler) filteredById(
 			result = append(result, r)
 		}
 	}
+	m.index.handOut(result...)
 	return result
 }
 
 // GetByCurrentId implements ResMap.
 func (m *resWrangler) GetByCurrentId(
 	id resid.ResId) (*resource.Resource, error) {
-	return demandOneMatch(m.GetMatchingResourcesByCurrentId, id, "Current")
+	return demandOneMatch(m.GetAllByCurrentId, id, "Current")
 }
 
 // GetByOriginalId implements ResMap.
 func (m *resWrangler) GetByOriginalId(
 	id resid.ResId) (*resource.Resource, error) {
-	return demandOneMatch(m.GetMatchingResourcesByOriginalId, id, "Original")
+	return demandOneMatch(m.GetAllByOriginalId, id, "Original")
 }
 
 // GetById implements ResMap.
@@ -436,11 +508,11 @@ func (m *resWrangler) GetById(
 		err1.Error(), err2.Error(), id.GvknString())
 }
 
-type resFinder func(IdMatcher) []*resource.Resource
+type resFinder func(resid.ResId) []*resource.Resource
 
 func demandOneMatch(
 	f resFinder, id resid.ResId, s string) (*resource.Resource, error) {
-	r := f(id.Equals)
+	r := f(id)
 	if len(r) == 1 {
 		return r[0], nil
 	}
@@ -452,32 +524,24 @@ func demandOneMatch(
 
 // GroupedByCurrentNamespace implements ResMap.GroupByCurrentNamespace
 func (m *resWrangler) GroupedByCurrentNamespace() map[string][]*resource.Resource {
-	items := m.groupedByCurrentNamespace()
+	items := m.index.groupedByNamespace()
 	delete(items, resid.TotallyNotANamespace)
+	m.index.handOutAll()
 	return items
 }
 
 // NonNamespaceable implements ResMap.NonNamespaceable
 func (m *resWrangler) NonNamespaceable() []*resource.Resource {
-	return m.groupedByCurrentNamespace()[resid.TotallyNotANamespace]
-}
-
-func (m *resWrangler) groupedByCurrentNamespace() map[string][]*resource.Resource {
-	byNamespace := make(map[string][]*resource.Resource)
-	for _, res := range m.rList {
-		namespace := res.CurId().EffectiveNamespace()
-		if _, found := byNamespace[namespace]; !found {
-			byNamespace[namespace] = []*resource.Resource{}
-		}
-		byNamespace[namespace] = append(byNamespace[namespace], res)
-	}
-	return byNamespace
+	result := resourcesOf(m.index.withNamespace(resid.TotallyNotANamespace))
+	m.index.handOut(result...)
+	return result
 }
 
 // GroupedByNamespace implements ResMap.GroupByOrginalNamespace
 func (m *resWrangler) GroupedByOriginalNamespace() map[string][]*resource.Resource {
 	items := m.groupedByOriginalNamespace()
 	delete(items, resid.TotallyNotANamespace)
+	m.index.handOutAll()
 	return items
 }
 
@@ -517,6 +581,54 @@ func (m *resWrangler) AsYaml() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// AsJson implements ResMap.
+func (m *resWrangler) AsJson() ([]byte, error) {
+	out, err := json.MarshalIndent(m.itemMaps(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// AsJsonLines implements ResMap.
+func (m *resWrangler) AsJsonLines() ([]byte, error) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	for _, res := range m.Resources() {
+		out, err := json.Marshal(res.Map())
+		if err != nil {
+			return nil, err
+		}
+		if _, err = buf.Write(out); err != nil {
+			return nil, err
+		}
+		if err = buf.WriteByte('\n'); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// AsList implements ResMap.
+func (m *resWrangler) AsList() ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      m.itemMaps(),
+	})
+}
+
+// itemMaps returns the map form of each resource,
+// as a non-nil slice so that an empty ResMap
+// encodes as an empty list rather than null.
+func (m *resWrangler) itemMaps() []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, m.Size())
+	for _, res := range m.rList {
+		result = append(result, res.Map())
+	}
+	return result
+}
+
 // ErrorIfNotEqualSets implements ResMap.
 func (m *resWrangler) ErrorIfNotEqualSets(other ResMap) error {
 	m2, ok := other.(*resWrangler)
@@ -580,6 +692,9 @@ type resCopier func(r *resource.Resource) *resource.Resource
 
 // ShallowCopy implements ResMap.
 func (m *resWrangler) ShallowCopy() ResMap {
+	// The copy's resources are these, so they
+	// may be changed through it.
+	m.index.handOutAll()
 	return m.makeCopy(
 		func(r *resource.Resource) *resource.Resource {
 			return r
@@ -596,10 +711,11 @@ func (m *resWrangler) DeepCopy() ResMap {
 
 // makeCopy copies the ResMap.
 func (m *resWrangler) makeCopy(copier resCopier) ResMap {
-	result := &resWrangler{}
+	result := newOne()
 	result.rList = make([]*resource.Resource, m.Size())
 	for i, r := range m.rList {
 		result.rList[i] = copier(r)
+		result.index.add(result.rList[i], i)
 	}
 	return result
 }
@@ -611,7 +727,7 @@ func (m *resWrangler) SubsetThatCouldBeReferencedByResource(
 	inputId := inputRes.CurId()
 	isInputIdNamespaceable := inputId.IsNamespaceableKind()
 	rctxm := inputRes.PrefixesSuffixesEquals
-	for _, r := range m.Resources() {
+	for _, r := range m.rList {
 		// Need to match more accuratly both at the time of selection and transformation.
 		// OutmostPrefixSuffixEquals is not accurate enough since it is only using
 		// the outer most suffix and the last prefix. Use PrefixedSuffixesEquals instead.
@@ -621,10 +737,12 @@ func (m *resWrangler) SubsetThatCouldBeReferencedByResource(
 			result.append(r)
 		}
 	}
+	m.index.handOut(result.rList...)
 	return result
 }
 
 func (m *resWrangler) append(res *resource.Resource) {
+	m.index.add(res, len(m.rList))
 	m.rList = append(m.rList, res)
 }
 
@@ -658,9 +776,9 @@ func (m *resWrangler) AbsorbAll(other ResMap) error {
 func (m *resWrangler) appendReplaceOrMerge(
 	res *resource.Resource) error {
 	id := res.CurId()
-	matches := m.GetMatchingResourcesByOriginalId(id.Equals)
+	matches := m.GetAllByOriginalId(id)
 	if len(matches) == 0 {
-		matches = m.GetMatchingResourcesByCurrentId(id.Equals)
+		matches = m.GetAllByCurrentId(id)
 	}
 	switch len(matches) {
 	case 0:
@@ -721,7 +839,7 @@ func (m *resWrangler) Select(s types.Selector) ([]*resource.Resource, error) {
 	ns := regexp.MustCompile(anchorRegex(s.Namespace))
 	nm := regexp.MustCompile(anchorRegex(s.Name))
 	var result []*resource.Resource
-	for _, r := range m.Resources() {
+	for _, r := range m.rList {
 		curId := r.CurId()
 		orgId := r.OrgId()
 
@@ -775,5 +893,6 @@ func (m *resWrangler) Select(s types.Selector) ([]*resource.Resource, error) {
 		}
 		result = append(result, r)
 	}
+	m.index.handOut(result...)
 	return result, nil
 }
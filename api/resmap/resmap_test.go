@@ -189,6 +189,93 @@ metadata:
 	}
 }
 
+func makeTwoCmResMap(t *testing.T) ResMap {
+	return resmaptest_test.NewRmBuilder(t, rf).Add(
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "cm1",
+			},
+		}).Add(
+		map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "cm2",
+			},
+		}).ResMap()
+}
+
+func TestEncodeAsJson(t *testing.T) {
+	encoded := []byte(`[
+  {
+    "apiVersion": "v1",
+    "kind": "ConfigMap",
+    "metadata": {
+      "name": "cm1"
+    }
+  },
+  {
+    "apiVersion": "v1",
+    "kind": "ConfigMap",
+    "metadata": {
+      "name": "cm2"
+    }
+  }
+]
+`)
+	out, err := makeTwoCmResMap(t).AsJson()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out, encoded) {
+		t.Fatalf("%s doesn't match expected %s", out, encoded)
+	}
+	out, err = New().AsJson()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "[]\n" {
+		t.Fatalf("expected empty array, got %s", out)
+	}
+}
+
+func TestEncodeAsJsonLines(t *testing.T) {
+	encoded := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm1"}}
+{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm2"}}
+`)
+	out, err := makeTwoCmResMap(t).AsJsonLines()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out, encoded) {
+		t.Fatalf("%s doesn't match expected %s", out, encoded)
+	}
+}
+
+func TestEncodeAsList(t *testing.T) {
+	encoded := []byte(`apiVersion: v1
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm1
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm2
+kind: List
+`)
+	out, err := makeTwoCmResMap(t).AsList()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out, encoded) {
+		t.Fatalf("%s doesn't match expected %s", out, encoded)
+	}
+}
+
 func TestGetMatchingResourcesByCurrentId(t *testing.T) {
 	r1 := rf.FromMap(
 		map[string]interface{}{
@@ -0,0 +1,90 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resource
+
+import (
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// OriginAnnotation is the annotation used to record
+// the origin of a resource in build output.
+const OriginAnnotation = "config.kubernetes.io/origin"
+
+// Origin records where a resource came from.
+//
+// A resource read from a file has a Path and Index.
+// A generated resource instead names the file that
+// configured the generator (ConfiguredIn) and the
+// generator itself (ConfiguredBy).
+//
+// Paths are relative to the root of the build, unless
+// the file came from a cloned git repository, in which
+// case Repo and Ref are set and paths are relative to
+// the top of the repository.  A resource read from a
+// remote file has the file's url as its Path.
+type Origin struct {
+	Path         string
+	Index        int
+	Repo         string
+	Ref          string
+	ConfiguredIn string
+	ConfiguredBy string
+}
+
+// InRepo returns true if the origin is in a git repository.
+func (o *Origin) InRepo() bool {
+	return o.Repo != ""
+}
+
+// Prepend returns a copy of the origin with the given
+// directory prepended to its paths.  Origins in repositories
+// or remote files are already complete, and are returned
+// unchanged.
+func (o *Origin) Prepend(dir string) *Origin {
+	if o.InRepo() || o.isRemoteFile() {
+		return o
+	}
+	result := *o
+	if result.Path != "" {
+		result.Path = filepath.Join(dir, result.Path)
+	}
+	if result.ConfiguredIn != "" {
+		result.ConfiguredIn = filepath.Join(dir, result.ConfiguredIn)
+	}
+	return &result
+}
+
+func (o *Origin) isRemoteFile() bool {
+	return strings.Contains(o.Path, "://")
+}
+
+// String returns the YAML form of the origin,
+// as used in the value of OriginAnnotation.
+func (o *Origin) String() string {
+	var c struct {
+		Path         string `json:"path,omitempty"`
+		Index        *int   `json:"index,omitempty"`
+		Repo         string `json:"repo,omitempty"`
+		Ref          string `json:"ref,omitempty"`
+		ConfiguredIn string `json:"configuredIn,omitempty"`
+		ConfiguredBy string `json:"configuredBy,omitempty"`
+	}
+	c.Path = o.Path
+	if o.Path != "" {
+		index := o.Index
+		c.Index = &index
+	}
+	c.Repo = o.Repo
+	c.Ref = o.Ref
+	c.ConfiguredIn = o.ConfiguredIn
+	c.ConfiguredBy = o.ConfiguredBy
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return strings.TrimSpace(string(out))
+}
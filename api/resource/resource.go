@@ -25,6 +25,7 @@ type Resource struct {
 	refVarNames  []string
 	namePrefixes []string
 	nameSuffixes []string
+	origin       *Origin
 }
 
 // ResCtx is an interface describing the contextual added
@@ -70,6 +71,7 @@ func (r *Resource) copyOtherFields(other *Resource) {
 	r.refVarNames = copyStringSlice(other.refVarNames)
 	r.namePrefixes = copyStringSlice(other.namePrefixes)
 	r.nameSuffixes = copyStringSlice(other.nameSuffixes)
+	r.origin = other.origin
 }
 
 func (r *Resource) Equals(o *Resource) bool {
@@ -242,6 +244,33 @@ func (r *Resource) AsYAML() ([]byte, error) {
 	return yaml.JSONToYAML(json)
 }
 
+// GetOrigin returns the origin of the resource,
+// nil if unknown.
+func (r *Resource) GetOrigin() *Origin {
+	return r.origin
+}
+
+// SetOrigin sets the origin of the resource.
+// Origins are treated as immutable, and may be
+// shared between copies of a resource.
+func (r *Resource) SetOrigin(o *Origin) {
+	r.origin = o
+}
+
+// SetOriginAnnotation records the origin of the
+// resource in its annotations, if the origin is known.
+func (r *Resource) SetOriginAnnotation() {
+	if r.origin == nil {
+		return
+	}
+	annotations := r.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[OriginAnnotation] = r.origin.String()
+	r.SetAnnotations(annotations)
+}
+
 // SetOptions updates the generator options for the resource.
 func (r *Resource) SetOptions(o *types.GenArgs) {
 	r.options = o
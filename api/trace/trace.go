@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package trace records how transformers change
+// resources, for explaining the result of a build.
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/kustomize/v3/api/diff"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+)
+
+// Action says what a transformer did to a resource.
+type Action string
+
+const (
+	Added    Action = "added"
+	Removed  Action = "removed"
+	Modified Action = "modified"
+)
+
+// ResourceChange is the set of changes made to one resource.
+type ResourceChange struct {
+	// Resource is the id of the resource after the change,
+	// or before the change if the resource was removed.
+	Resource string             `json:"resource"`
+	Action   Action             `json:"action"`
+	Fields   []diff.FieldChange `json:"fields,omitempty"`
+}
+
+// Step records the changes made by one transformer.
+type Step struct {
+	// Root is the root of the kustomization
+	// that ran the transformer.
+	Root        string           `json:"root"`
+	Transformer string           `json:"transformer"`
+	Changes     []ResourceChange `json:"changes,omitempty"`
+}
+
+// Trace is the sequence of steps in a build.
+// A nil Trace records nothing.
+type Trace struct {
+	Steps []Step `json:"steps"`
+}
+
+// New returns an empty trace.
+func New() *Trace {
+	return &Trace{Steps: []Step{}}
+}
+
+// Wrap returns a transformer that runs the given
+// transformer, recording its changes as a step
+// with the given root and transformer name.
+// If the trace is nil, the transformer is
+// returned unchanged.
+func (t *Trace) Wrap(
+	root, name string, tr resmap.Transformer) resmap.Transformer {
+	if t == nil {
+		return tr
+	}
+	return &tracingTransformer{
+		trace: t, root: root, name: name, delegate: tr}
+}
+
+type tracingTransformer struct {
+	trace    *Trace
+	root     string
+	name     string
+	delegate resmap.Transformer
+}
+
+func (tt *tracingTransformer) Transform(m resmap.ResMap) error {
+	before := snapshot(m)
+	err := tt.delegate.Transform(m)
+	if err != nil {
+		return err
+	}
+	tt.trace.Steps = append(tt.trace.Steps, Step{
+		Root:        tt.root,
+		Transformer: tt.name,
+		Changes:     changes(before, m),
+	})
+	return nil
+}
+
+// state is a copy of a resource's content,
+// kept to compare against after a transformation.
+type state struct {
+	id     string
+	object map[string]interface{}
+}
+
+type snapshotResult struct {
+	resources []*resource.Resource
+	states    map[*resource.Resource]state
+}
+
+// snapshot copies the content of the resources in the
+// map, keyed by the resources themselves so they can be
+// found after a transformation modifies them in place.
+func snapshot(m resmap.ResMap) snapshotResult {
+	rs := m.Resources()
+	states := make(map[*resource.Resource]state, len(rs))
+	for _, r := range rs {
+		states[r] = state{
+			id:     r.CurId().String(),
+			object: r.DeepCopy().Map(),
+		}
+	}
+	return snapshotResult{resources: rs, states: states}
+}
+
+func changes(before snapshotResult, m resmap.ResMap) []ResourceChange {
+	var result []ResourceChange
+	seen := make(map[*resource.Resource]bool)
+	for _, r := range m.Resources() {
+		seen[r] = true
+		old, ok := before.states[r]
+		if !ok {
+			result = append(result, ResourceChange{
+				Resource: r.CurId().String(),
+				Action:   Added,
+			})
+			continue
+		}
+		fields := diff.Fields(old.object, r.Map(), nil)
+		if len(fields) > 0 {
+			result = append(result, ResourceChange{
+				Resource: r.CurId().String(),
+				Action:   Modified,
+				Fields:   fields,
+			})
+		}
+	}
+	for _, r := range before.resources {
+		if !seen[r] {
+			result = append(result, ResourceChange{
+				Resource: before.states[r].id,
+				Action:   Removed,
+			})
+		}
+	}
+	return result
+}
+
+// AsJson returns the trace as indented JSON.
+func (t *Trace) AsJson() ([]byte, error) {
+	out, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Report returns the trace as a human-readable report.
+func (t *Trace) Report() []byte {
+	var b bytes.Buffer
+	for i, s := range t.Steps {
+		fmt.Fprintf(&b, "step %d: %s in %s\n", i+1, s.Transformer, s.Root)
+		if len(s.Changes) == 0 {
+			b.WriteString("  no changes\n")
+			continue
+		}
+		for _, c := range s.Changes {
+			fmt.Fprintf(&b, "  %s %s\n", c.Action, c.Resource)
+			for _, f := range c.Fields {
+				fmt.Fprintf(&b, "    %s\n", f)
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// Append adds the steps of the other trace, which may be
+// nil, to this one, e.g. steps recorded apart from this
+// trace, concurrently with the steps recorded in it.
+func (t *Trace) Append(other *Trace) {
+	if t == nil || other == nil {
+		return
+	}
+	t.Steps = append(t.Steps, other.Steps...)
+}
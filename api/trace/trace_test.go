@@ -0,0 +1,150 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package trace_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/diff"
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/testutils/resmaptest"
+	. "sigs.k8s.io/kustomize/v3/api/trace"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+)
+
+var rf = resource.NewFactory(
+	kunstruct.NewKunstructuredFactoryImpl())
+
+type transformerFunc func(m resmap.ResMap) error
+
+func (f transformerFunc) Transform(m resmap.ResMap) error {
+	return f(m)
+}
+
+func makeResMap(t *testing.T) resmap.ResMap {
+	return resmaptest_test.NewRmBuilder(t, rf).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "cm1",
+			},
+			"data": map[string]interface{}{
+				"a": "x",
+				"b": "y",
+			},
+		}).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "cm2",
+			},
+		}).ResMap()
+}
+
+func TestNilTraceDoesNotWrap(t *testing.T) {
+	var tr *Trace
+	f := transformerFunc(func(m resmap.ResMap) error { return nil })
+	if _, ok := tr.Wrap("/app", "f", f).(transformerFunc); !ok {
+		t.Fatalf("expected unwrapped transformer")
+	}
+}
+
+func TestWrapRecordsChanges(t *testing.T) {
+	tr := New()
+	m := makeResMap(t)
+	err := tr.Wrap("/app", "first", transformerFunc(
+		func(m resmap.ResMap) error {
+			r := m.GetByIndex(0)
+			r.SetName("p-cm1")
+			data := r.Map()["data"].(map[string]interface{})
+			data["a"] = "z"
+			delete(data, "b")
+			return nil
+		})).Transform(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tr.Wrap("/app/base", "second", transformerFunc(
+		func(m resmap.ResMap) error {
+			return m.Remove(resid.NewResId(
+				resid.Gvk{Version: "v1", Kind: "ConfigMap"}, "cm2"))
+		})).Transform(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Step{
+		{
+			Root:        "/app",
+			Transformer: "first",
+			Changes: []ResourceChange{
+				{
+					Resource: "~G_v1_ConfigMap|~X|p-cm1",
+					Action:   Modified,
+					Fields: []diff.FieldChange{
+						{Path: "data/a", Old: "x", New: "z"},
+						{Path: "data/b", Old: "y"},
+						{Path: "metadata/name", Old: "cm1", New: "p-cm1"},
+					},
+				},
+			},
+		},
+		{
+			Root:        "/app/base",
+			Transformer: "second",
+			Changes: []ResourceChange{
+				{
+					Resource: "~G_v1_ConfigMap|~X|cm2",
+					Action:   Removed,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(tr.Steps, expected) {
+		t.Fatalf("expected\n%v\nbut got\n%v", expected, tr.Steps)
+	}
+	report := `step 1: first in /app
+  modified ~G_v1_ConfigMap|~X|p-cm1
+    data/a: "x" -> "z"
+    data/b: "y" -> <none>
+    metadata/name: "cm1" -> "p-cm1"
+step 2: second in /app/base
+  removed ~G_v1_ConfigMap|~X|cm2
+`
+	if string(tr.Report()) != report {
+		t.Fatalf("expected\n%s\nbut got\n%s", report, tr.Report())
+	}
+}
+
+func TestWrapRecordsUnchangedStep(t *testing.T) {
+	tr := New()
+	err := tr.Wrap("/app", "noop", transformerFunc(
+		func(m resmap.ResMap) error { return nil })).Transform(makeResMap(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tr.Steps) != 1 || len(tr.Steps[0].Changes) != 0 {
+		t.Fatalf("unexpected steps %v", tr.Steps)
+	}
+	out, err := tr.AsJson()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+  "steps": [
+    {
+      "root": "/app",
+      "transformer": "noop"
+    }
+  ]
+}
+`
+	if string(out) != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out)
+	}
+}
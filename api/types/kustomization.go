@@ -6,6 +6,15 @@ package types
 const (
 	KustomizationVersion = "kustomize.config.k8s.io/v1beta1"
 	KustomizationKind    = "Kustomization"
+	ComponentVersion     = "kustomize.config.k8s.io/v1alpha1"
+	ComponentKind        = "Component"
+)
+
+// Recognized values of the BuildMetadata field.
+const (
+	// OriginAnnotations requests that each resource be
+	// annotated with the file or generator it came from.
+	OriginAnnotations = "originAnnotations"
 )
 
 // Kustomization holds the information needed to generate customized k8s api resources.
@@ -33,6 +42,11 @@ type Kustomization struct {
 	// CommonAnnotations to add to all objects.
 	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
 
+	// Exclude removes the resources, e.g. those of a base,
+	// that any of these selectors pick.  Each selector
+	// must pick at least one resource.
+	Exclude []Selector `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
 	// PatchesStrategicMerge specifies the relative path to a file
 	// containing a strategic merge patch.  Format documented at
 	// https://github.com/kubernetes/community/blob/master/contributors/devel/strategic-merge-patch.md
@@ -67,6 +81,12 @@ type Kustomization struct {
 	// value of the specified field has been determined.
 	Vars []Var `json:"vars,omitempty" yaml:"vars,omitempty"`
 
+	// Replacements copy the values of fields into other
+	// fields, in any resource, without needing placeholders.
+	// They run after the other fields' transformations, and
+	// before the plugins listed in Transformers.
+	Replacements []Replacement `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+
 	//
 	// Operands - what kustomize operates on.
 	//
@@ -76,6 +96,13 @@ type Kustomization struct {
 	// via relative paths, absolute paths, or URLs.
 	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
 
+	// Components specifies relative paths or URLs of kustomizations
+	// of kind Component.  Unlike those in Resources, which are built
+	// in isolation, a component's generators and transformers apply
+	// to the resources accumulated so far, i.e. those of Resources
+	// and of the components listed before it.
+	Components []string `json:"components,omitempty" yaml:"components,omitempty"`
+
 	// Crds specifies relative paths to Custom Resource Definition files.
 	// This allows custom resources to be recognized as operands, making
 	// it possible to add them to the Resources list.
@@ -120,6 +147,28 @@ type Kustomization struct {
 	// Inventory appends an object that contains the record
 	// of all other objects, which can be used in apply, prune and delete
 	Inventory *Inventory `json:"inventory,omitempty" yaml:"inventory,omitempty"`
+
+	// SortOptions change the order of resources in the build output.
+	// Only honored in the kustomization at the root of the build.
+	SortOptions *SortOptions `json:"sortOptions,omitempty" yaml:"sortOptions,omitempty"`
+
+	// Variants are named sets of parameters, each applied
+	// to the output of this kustomization as if by an overlay.
+	// Only honored in the kustomization at the root of the
+	// build, when building all variants.
+	Variants []Variant `json:"variants,omitempty" yaml:"variants,omitempty"`
+
+	// BuildMetadata is a list of kinds of metadata to record
+	// on resources in the build output, e.g. originAnnotations.
+	// Applies to this kustomization and to everything it
+	// accumulates from its bases.
+	BuildMetadata []string `json:"buildMetadata,omitempty" yaml:"buildMetadata,omitempty"`
+
+	// AllowDirs is a list of directories, relative to this
+	// kustomization, from which it may load files when
+	// building with --load_restrictor allowlist.  Only the
+	// kustomization being built may allow directories.
+	AllowDirs []string `json:"allowDirs,omitempty" yaml:"allowDirs,omitempty"`
 }
 
 // FixKustomizationPostUnmarshalling fixes things
@@ -127,25 +176,54 @@ type Kustomization struct {
 // moving content of deprecated fields to newer
 // fields.
 func (k *Kustomization) FixKustomizationPostUnmarshalling() {
-	if k.APIVersion == "" {
-		k.APIVersion = KustomizationVersion
-	}
 	if k.Kind == "" {
 		k.Kind = KustomizationKind
 	}
+	if k.APIVersion == "" {
+		if k.Kind == ComponentKind {
+			k.APIVersion = ComponentVersion
+		} else {
+			k.APIVersion = KustomizationVersion
+		}
+	}
 	for _, b := range k.Bases {
 		k.Resources = append(k.Resources, b)
 	}
 	k.Bases = nil
 }
 
+// HasBuildMetadata returns true if the given
+// kind of build metadata is requested.
+func (k *Kustomization) HasBuildMetadata(m string) bool {
+	for _, x := range k.BuildMetadata {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
 func (k *Kustomization) EnforceFields() []string {
 	var errs []string
-	if k.APIVersion != "" && k.APIVersion != KustomizationVersion {
-		errs = append(errs, "apiVersion should be "+KustomizationVersion)
+	if k.Kind == ComponentKind {
+		if k.APIVersion != "" && k.APIVersion != ComponentVersion {
+			errs = append(errs, "apiVersion for "+ComponentKind+
+				" should be "+ComponentVersion)
+		}
+	} else {
+		if k.APIVersion != "" && k.APIVersion != KustomizationVersion {
+			errs = append(errs, "apiVersion should be "+KustomizationVersion)
+		}
+		if k.Kind != "" && k.Kind != KustomizationKind {
+			errs = append(errs, "kind should be "+KustomizationKind+
+				" or "+ComponentKind)
+		}
 	}
-	if k.Kind != "" && k.Kind != KustomizationKind {
-		errs = append(errs, "kind should be "+KustomizationKind)
+	for _, m := range k.BuildMetadata {
+		if m != OriginAnnotations {
+			errs = append(errs, "unknown buildMetadata "+m+
+				"; legal values: "+OriginAnnotations)
+		}
 	}
 	return errs
 }
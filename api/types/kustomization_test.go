@@ -0,0 +1,55 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"testing"
+)
+
+func TestEnforceFieldsBuildMetadata(t *testing.T) {
+	k := Kustomization{BuildMetadata: []string{OriginAnnotations}}
+	if errs := k.EnforceFields(); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !k.HasBuildMetadata(OriginAnnotations) {
+		t.Fatalf("expected %s", OriginAnnotations)
+	}
+	k.BuildMetadata = append(k.BuildMetadata, "bogus")
+	errs := k.EnforceFields()
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+}
+
+func TestEnforceFieldsKind(t *testing.T) {
+	testCases := []struct {
+		apiVersion string
+		kind       string
+		errs       int
+	}{
+		{"", "", 0},
+		{KustomizationVersion, KustomizationKind, 0},
+		{ComponentVersion, ComponentKind, 0},
+		{"", ComponentKind, 0},
+		{KustomizationVersion, ComponentKind, 1},
+		{ComponentVersion, KustomizationKind, 1},
+		{"", "Bogus", 1},
+	}
+	for _, tc := range testCases {
+		k := Kustomization{
+			TypeMeta: TypeMeta{APIVersion: tc.apiVersion, Kind: tc.kind}}
+		if errs := k.EnforceFields(); len(errs) != tc.errs {
+			t.Errorf("%s %s: expected %d errors, got %v",
+				tc.apiVersion, tc.kind, tc.errs, errs)
+		}
+	}
+}
+
+func TestFixKustomizationPostUnmarshallingComponent(t *testing.T) {
+	k := Kustomization{TypeMeta: TypeMeta{Kind: ComponentKind}}
+	k.FixKustomizationPostUnmarshalling()
+	if k.APIVersion != ComponentVersion {
+		t.Fatalf("unexpected apiVersion %s", k.APIVersion)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// Lock pins the remote git repositories reached
+// in a build to the commits they were at when the
+// lock was made, so that the build is reproducible.
+// It's held in a file beside the kustomization file.
+type Lock struct {
+	Remotes []LockedRemote `json:"remotes,omitempty" yaml:"remotes,omitempty"`
+}
+
+// LockedRemote pins one ref of a remote repository to a commit.
+type LockedRemote struct {
+	// Repo is the clone spec of the repository,
+	// e.g. https://github.com/someOrg/someRepo.git
+	Repo string `json:"repo" yaml:"repo"`
+
+	// Ref is the ref as given in the kustomization,
+	// or empty if none was given.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+
+	// Commit is the commit the ref was resolved to.
+	Commit string `json:"commit" yaml:"commit"`
+}
+
+// CommitOf returns the commit pinned for the
+// repo and ref, or the empty string if none.
+func (l *Lock) CommitOf(repo, ref string) string {
+	if l == nil {
+		return ""
+	}
+	for _, r := range l.Remotes {
+		if r.Repo == repo && r.Ref == ref {
+			return r.Commit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// DefaultReplacementFieldPath is the field copied
+// from a source that doesn't name one.
+const DefaultReplacementFieldPath = "metadata.name"
+
+// Replacement copies the value of a field in one
+// resource, the source, into fields of other resources,
+// the targets.  Unlike a Var, it needs no placeholder in
+// the targets, and may copy structured values, i.e. maps
+// and lists, as well as strings and numbers.
+type Replacement struct {
+	// The field to copy.
+	Source *ReplacementSource `json:"source,omitempty" yaml:"source,omitempty"`
+
+	// The fields to copy it to.
+	Targets []*ReplacementTarget `json:"targets,omitempty" yaml:"targets,omitempty"`
+}
+
+// ReplacementSource names a field of exactly one resource.
+type ReplacementSource struct {
+	Selector `json:",inline,omitempty" yaml:",inline,omitempty"`
+
+	// FieldPath is a dot separated path to the field,
+	// e.g. spec.template.spec.containers[name=web].image,
+	// where a list element is picked by its index, as in
+	// [0], or by the value of one of its fields, as in
+	// [name=web].  Defaults to DefaultReplacementFieldPath.
+	FieldPath string `json:"fieldPath,omitempty" yaml:"fieldPath,omitempty"`
+}
+
+// ReplacementTarget names fields of one or more resources.
+type ReplacementTarget struct {
+	// Select picks the target resources.
+	// It must pick at least one.
+	Select *Selector `json:"select,omitempty" yaml:"select,omitempty"`
+
+	// FieldPaths are paths, in the syntax of
+	// ReplacementSource.FieldPath, to fields that
+	// must exist in every target resource.
+	FieldPaths []string `json:"fieldPaths,omitempty" yaml:"fieldPaths,omitempty"`
+
+	// Options allow replacing just part of a field.
+	Options *ReplacementOptions `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// ReplacementOptions allow replacing part of a string
+// field, e.g. the tag of an image.  The field is split
+// by Delimiter, the part at Index is replaced, and the
+// parts are joined again.
+type ReplacementOptions struct {
+	Delimiter string `json:"delimiter,omitempty" yaml:"delimiter,omitempty"`
+	Index     int    `json:"index,omitempty" yaml:"index,omitempty"`
+}
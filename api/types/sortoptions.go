@@ -0,0 +1,20 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// SortOptions configures the order of resources in
+// the final output of a build.
+//
+// Regardless of these options, Namespaces and
+// CustomResourceDefinitions are always placed before
+// the resources that might live in them or use them,
+// and admission webhook configurations are always
+// placed last, so that they cannot intercept the
+// creation of the other resources in the build.
+type SortOptions struct {
+	// KindPriority lists kinds in the order in which
+	// they should appear.  Kinds not in the list follow
+	// all listed kinds, in the legacy kind order.
+	KindPriority []string `json:"kindPriority,omitempty" yaml:"kindPriority,omitempty"`
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// Variant is a named set of parameters.  A variant's
+// output is that of an overlay holding the variant's
+// fields and listing the kustomization that holds the
+// variant as its only resource.
+type Variant struct {
+	// Name identifies the variant, and names the
+	// directory its output is written to.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	NamePrefix        string            `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+	NameSuffix        string            `json:"nameSuffix,omitempty" yaml:"nameSuffix,omitempty"`
+	Namespace         string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	CommonLabels      map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
+	Images            []Image           `json:"images,omitempty" yaml:"images,omitempty"`
+	Replicas          []Replica         `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+
+	// ConfigMapGenerator and SecretGenerator typically
+	// hold literals merged into the kustomization's own
+	// generated ConfigMaps and Secrets, i.e. with
+	// behavior: merge.
+	ConfigMapGenerator []ConfigMapArgs `json:"configMapGenerator,omitempty" yaml:"configMapGenerator,omitempty"`
+	SecretGenerator    []SecretArgs    `json:"secretGenerator,omitempty" yaml:"secretGenerator,omitempty"`
+}
+
+// Kustomization returns the fields of the variant as
+// those of a kustomization, without resources.
+func (v *Variant) Kustomization() *Kustomization {
+	return &Kustomization{
+		TypeMeta: TypeMeta{
+			APIVersion: KustomizationVersion,
+			Kind:       KustomizationKind,
+		},
+		NamePrefix:         v.NamePrefix,
+		NameSuffix:         v.NameSuffix,
+		Namespace:          v.Namespace,
+		CommonLabels:       v.CommonLabels,
+		CommonAnnotations:  v.CommonAnnotations,
+		Images:             v.Images,
+		Replicas:           v.Replicas,
+		ConfigMapGenerator: v.ConfigMapGenerator,
+		SecretGenerator:    v.SecretGenerator,
+	}
+}
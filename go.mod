@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/evanphx/json-patch v4.5.0+incompatible
+	github.com/go-git/go-git/v5 v5.2.0
 	github.com/go-openapi/spec v0.19.2
 	github.com/golangci/golangci-lint v1.19.1
 	github.com/gorilla/mux v1.7.3 // indirect
@@ -11,9 +12,8 @@ require (
 	github.com/monopole/mdrip v1.0.0
 	github.com/pkg/errors v0.8.1
 	github.com/spf13/pflag v1.0.5
-	golang.org/x/net v0.0.0-20191011234655-491137f69257 // indirect
 	golang.org/x/tools v0.0.0-20191014141550-5fa5b1782b2c // indirect
-	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v2 v2.2.4
 	k8s.io/api v0.0.0-20190313235455-40a48860b5ab
 	k8s.io/apimachinery v0.0.0-20190313205120-d7deff9243b1
 	k8s.io/client-go v11.0.0+incompatible
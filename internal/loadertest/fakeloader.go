@@ -70,6 +70,25 @@ func (f FakeLoader) Load(location string) ([]byte, error) {
 	return f.delegate.Load(location)
 }
 
+// LoadRemoteFile delegates.
+func (f FakeLoader) LoadRemoteFile(url string) ([]byte, error) {
+	return f.delegate.LoadRemoteFile(url)
+}
+
+// Repo delegates.
+func (f FakeLoader) Repo() (string, string, string) {
+	return f.delegate.Repo()
+}
+
+// Allow delegates.
+func (f FakeLoader) Allow(dirs []string) (ifc.Loader, error) {
+	l, err := f.delegate.Allow(dirs)
+	if err != nil {
+		return nil, err
+	}
+	return FakeLoader{fs: f.fs, delegate: l}, nil
+}
+
 // Cleanup delegates.
 func (f FakeLoader) Cleanup() error {
 	return f.delegate.Cleanup()
@@ -141,7 +141,7 @@ func MergePatches(patches []*resource.Resource,
 	rc := resmap.New()
 	for ix, patch := range patches {
 		id := patch.OrgId()
-		existing := rc.GetMatchingResourcesByOriginalId(id.Equals)
+		existing := rc.GetAllByOriginalId(id)
 		if len(existing) == 0 {
 			rc.Append(patch)
 			continue
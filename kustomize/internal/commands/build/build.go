@@ -4,7 +4,9 @@
 package build
 
 import (
+	"fmt"
 	"io"
+	"log"
 	"path/filepath"
 	"strings"
 
@@ -15,19 +17,40 @@ import (
 	"sigs.k8s.io/kustomize/v3/api/loader"
 	"sigs.k8s.io/kustomize/v3/api/resmap"
 	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/trace"
+	"sigs.k8s.io/kustomize/v3/api/types"
 	"sigs.k8s.io/kustomize/v3/pkg/pgmconfig"
 	"sigs.k8s.io/kustomize/v3/pkg/plugins"
 	"sigs.k8s.io/kustomize/v3/pkg/target"
-	"sigs.k8s.io/kustomize/v3/plugin/builtin"
 	"sigs.k8s.io/yaml"
 )
 
+const flagAllVariantsName = "all-variants"
+
 // Options contain the options for running a build
 type Options struct {
 	kustomizationPath string
 	outputPath        string
 	loadRestrictor    loader.LoadRestrictorFunc
 	outOrder          reorderOutput
+	outOrderSet       bool
+	outFormat         outputFormat
+	outLayout         outputLayout
+	enableOrigin      bool
+	explain           explainMode
+	profile           profileMode
+	profileOut        io.Writer
+	offline           bool
+	gitSubPathOnly    bool
+	locked            bool
+	mirrors           map[string]string
+	allVariants       bool
+	overlay           *types.Kustomization
+	strict            bool
+	selects           []types.Selector
+	excludes          []types.Selector
+	watch             bool
+	watchDiff         bool
 }
 
 // NewOptions creates a Options object
@@ -77,6 +100,8 @@ func NewCmdBuild(
 			if err != nil {
 				return err
 			}
+			o.outOrderSet = cmd.Flags().Changed(flagReorderOutputName)
+			o.profileOut = cmd.ErrOrStderr()
 			return o.RunBuild(out, v, fSys, rf, ptf, pl)
 		},
 	}
@@ -86,9 +111,59 @@ func NewCmdBuild(
 		"output", "o", "",
 		"If specified, write the build output to this path.")
 	loader.AddFlagLoadRestrictor(cmd.Flags())
+	loader.AddFlagGitMirrors(cmd.Flags())
 	plugins.AddFlagEnablePlugins(
 		cmd.Flags(), &pluginConfig.Enabled)
 	addFlagReorderOutput(cmd.Flags())
+	addFlagOutputFormat(cmd.Flags())
+	addFlagOutputLayout(cmd.Flags())
+	addFlagExplain(cmd.Flags())
+	addFlagProfile(cmd.Flags())
+	addFlagsOverrides(cmd.Flags())
+	addFlagsVars(cmd.Flags())
+	addFlagsSelectors(cmd.Flags())
+	cmd.Flags().BoolVar(
+		&o.watch,
+		flagWatchName, false,
+		"If true, build again whenever a file or directory read by the "+
+			"build changes, until interrupted.  Remote bases and files "+
+			"are fetched once, and not watched.")
+	cmd.Flags().BoolVar(
+		&o.watchDiff,
+		flagWatchDiffName, false,
+		"If true, with --"+flagWatchName+", print the differences from "+
+			"the previous output, rather than the whole output, after "+
+			"the first build.")
+	cmd.Flags().BoolVar(
+		&o.offline,
+		"offline", false,
+		"If true, take remote bases only from the local cache of git clones.")
+	cmd.Flags().BoolVar(
+		&o.gitSubPathOnly,
+		"git-sub-path-only", false,
+		"If true, check out only the path of each remote base within its "+
+			"repository, in programs built with the gogit tag.  Such a base "+
+			"can't refer to files outside its path.")
+	cmd.Flags().BoolVar(
+		&o.locked,
+		"locked", false,
+		"If true, fail if a remote base isn't pinned in "+loader.LockFileName+".")
+	cmd.Flags().BoolVar(
+		&o.strict,
+		"strict", false,
+		"If true, fail on problems that are otherwise ignored or only "+
+			"logged, e.g. references to undefined or unused vars, or "+
+			"patches that match or change nothing, and images entries that "+
+			"match nothing.")
+	cmd.Flags().BoolVar(
+		&o.allVariants,
+		flagAllVariantsName, false,
+		"If true, write the output of each of the kustomization's variants "+
+			"to its own directory in the --output directory.")
+	cmd.Flags().BoolVar(
+		&o.enableOrigin,
+		"enable-origin", false,
+		"If true, annotate each resource with the file or generator it came from.")
 	cmd.AddCommand(NewCmdBuildPrune(out, v, fSys, rf, ptf, pl))
 	return cmd
 }
@@ -109,8 +184,65 @@ func (o *Options) Validate(args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	o.mirrors, err = loader.ValidateFlagGitMirrors()
+	if err != nil {
+		return err
+	}
 	o.outOrder, err = validateFlagReorderOutput()
-	return
+	if err != nil {
+		return err
+	}
+	o.outFormat, err = validateFlagOutputFormat()
+	if err != nil {
+		return err
+	}
+	o.outLayout, err = validateFlagOutputLayout()
+	if err != nil {
+		return err
+	}
+	o.explain, err = validateFlagExplain()
+	if err != nil {
+		return err
+	}
+	o.profile, err = validateFlagProfile()
+	if err != nil {
+		return err
+	}
+	o.overlay, err = validateFlagsOverrides()
+	if err != nil {
+		return err
+	}
+	o.selects, o.excludes, err = validateFlagsSelectors()
+	if err != nil {
+		return err
+	}
+	if o.outLayout != unspecifiedLayout {
+		if o.outputPath == "" {
+			return fmt.Errorf(
+				"--%s requires --output, the directory to write to",
+				flagOutputLayoutName)
+		}
+		if o.explain != noExplain {
+			return fmt.Errorf(
+				"--%s cannot be used with --%s", flagExplainName, flagOutputLayoutName)
+		}
+	}
+	err = o.validateFlagsWatch()
+	if err != nil {
+		return err
+	}
+	if o.allVariants {
+		if o.outputPath == "" {
+			return fmt.Errorf(
+				"--%s requires --output, the directory to write variants to",
+				flagAllVariantsName)
+		}
+		if o.explain != noExplain {
+			return fmt.Errorf(
+				"--%s cannot be used with --%s", flagExplainName, flagAllVariantsName)
+		}
+	}
+	return nil
 }
 
 // RunBuild runs build command.
@@ -118,29 +250,122 @@ func (o *Options) RunBuild(
 	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
 	rf *resmap.Factory, ptf resmap.PatchFactory,
 	pl *plugins.Loader) error {
-	ldr, err := loader.NewLoader(
-		o.loadRestrictor, o.kustomizationPath, fSys)
+	if o.watch {
+		return o.runWatch(out, v, fSys, rf, ptf, pl, nil)
+	}
+	ldr, err := o.newLoader(fSys)
 	if err != nil {
 		return err
 	}
 	defer ldr.Cleanup()
-	kt, err := target.NewKustTarget(ldr, v, rf, ptf, pl)
+	kt, err := o.newTarget(ldr, v, fSys, rf, ptf, pl)
+	if err != nil {
+		return err
+	}
+	p := o.profile.start(kt)
+	err = o.buildTarget(out, fSys, kt)
 	if err != nil {
 		return err
 	}
+	return o.profile.emit(o.profileOut, p)
+}
+
+// buildTarget builds the target, and emits
+// the output the flags ask for.
+func (o *Options) buildTarget(
+	out io.Writer, fSys filesys.FileSystem, kt *target.KustTarget) error {
+	if o.allVariants {
+		vs, err := kt.MakeCustomizedVariants()
+		if err != nil {
+			return err
+		}
+		return o.emitVariants(fSys, kt, vs)
+	}
+	if kt.HasVariants() {
+		log.Printf(
+			"kustomization in '%s' has variants, "+
+				"which are built only with --%s",
+			o.kustomizationPath, flagAllVariantsName)
+	}
+	var t *trace.Trace
+	if o.explain != noExplain {
+		t = trace.New()
+		kt.SetTrace(t)
+	}
 	m, err := kt.MakeCustomizedResMap()
 	if err != nil {
 		return err
 	}
-	return o.emitResources(out, fSys, m)
+	if t != nil {
+		return o.emitTrace(out, fSys, t)
+	}
+	err = filterResources(m, o.selects, o.excludes)
+	if err != nil {
+		return err
+	}
+	bo := o.emitOptions(kt)
+	return bo.emitResources(out, fSys, m)
+}
+
+// emitOptions returns a copy of the options, with which
+// to emit the output of the target, without changing the
+// options, as those persist between builds in watch mode.
+func (o *Options) emitOptions(kt *target.KustTarget) Options {
+	eo := *o
+	if kt.SortsOutput() && !o.outOrderSet {
+		// Respect the order specified in the kustomization.
+		eo.outOrder = none
+	}
+	return eo
+}
+
+// newTarget returns the target of the build at the
+// loader's root, customized per the flags.
+func (o *Options) newTarget(
+	ldr ifc.Loader, v ifc.Validator, fSys filesys.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader) (*target.KustTarget, error) {
+	kt, err := target.NewKustTarget(ldr, v, rf, ptf, pl)
+	if err != nil {
+		return nil, err
+	}
+	if o.enableOrigin {
+		kt.EnableOriginAnnotations()
+	}
+	if o.overlay != nil {
+		kt.SetOverlay(o.overlay)
+	}
+	values, err := readFlagsVars(fSys, v)
+	if err != nil {
+		return nil, err
+	}
+	kt.SetVarValues(values)
+	kt.SetStrict(o.strict)
+	return kt, nil
+}
+
+// newLoader returns a loader at the kustomization,
+// which pins remote bases per its lock file, if any.
+func (o *Options) newLoader(fSys filesys.FileSystem) (ifc.Loader, error) {
+	pins, err := loader.ReadLock(fSys, o.kustomizationPath)
+	if err != nil {
+		return nil, err
+	}
+	return loader.NewCachingLoader(
+		o.loadRestrictor, o.kustomizationPath, fSys,
+		loader.RemoteOptions{
+			Offline:     o.offline,
+			Locker:      loader.NewLocker(pins, o.locked),
+			Mirrors:     o.mirrors,
+			SubPathOnly: o.gitSubPathOnly,
+		})
 }
 
 func (o *Options) RunBuildPrune(
 	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
 	rf *resmap.Factory, ptf resmap.PatchFactory,
 	pl *plugins.Loader) error {
-	ldr, err := loader.NewLoader(
-		o.loadRestrictor, o.kustomizationPath, fSys)
+	ldr, err := o.newLoader(fSys)
 	if err != nil {
 		return err
 	}
@@ -158,17 +383,25 @@ func (o *Options) RunBuildPrune(
 
 func (o *Options) emitResources(
 	out io.Writer, fSys filesys.FileSystem, m resmap.ResMap) error {
-	if o.outputPath != "" && fSys.IsDir(o.outputPath) {
-		return writeIndividualFiles(fSys, o.outputPath, m)
+	if o.outputPath != "" &&
+		(o.outLayout != unspecifiedLayout || fSys.IsDir(o.outputPath)) {
+		if o.outFormat != yamlFormat {
+			return fmt.Errorf(
+				"--%s %s cannot be used when writing to directory '%s'",
+				flagOutputFormatName, o.outFormat, o.outputPath)
+		}
+		if o.outLayout == unspecifiedLayout {
+			return writeIndividualFiles(fSys, o.outputPath, m)
+		}
 	}
-	if o.outOrder == legacy {
-		// Done this way just to show how overall sorting
-		// can be performed by a plugin.  This particular
-		// plugin doesn't require configuration; just make
-		// it and call transform.
-		builtin.NewLegacyOrderTransformerPlugin().Transform(m)
+	err := o.outOrder.reorder(m)
+	if err != nil {
+		return err
+	}
+	if o.outLayout != unspecifiedLayout {
+		return o.outLayout.write(fSys, o.outputPath, m)
 	}
-	res, err := m.AsYaml()
+	res, err := o.outFormat.encode(m)
 	if err != nil {
 		return err
 	}
@@ -179,6 +412,47 @@ func (o *Options) emitResources(
 	return err
 }
 
+// emitVariants writes the output of each variant of
+// the target to its own directory in the output directory.
+func (o *Options) emitVariants(
+	fSys filesys.FileSystem, kt *target.KustTarget,
+	vs []target.Variant) error {
+	if fSys.Exists(o.outputPath) && !fSys.IsDir(o.outputPath) {
+		return fmt.Errorf("'%s' is not a directory", o.outputPath)
+	}
+	for _, v := range vs {
+		dir := filepath.Join(o.outputPath, v.Name)
+		err := fSys.MkdirAll(dir)
+		if err != nil {
+			return err
+		}
+		err = filterResources(v.ResMap, o.selects, o.excludes)
+		if err != nil {
+			return errors.Wrapf(err, "variant '%s'", v.Name)
+		}
+		vo := o.emitOptions(kt)
+		vo.outputPath = dir
+		err = vo.emitResources(nil, fSys, v.ResMap)
+		if err != nil {
+			return errors.Wrapf(err, "variant '%s'", v.Name)
+		}
+	}
+	return nil
+}
+
+func (o *Options) emitTrace(
+	out io.Writer, fSys filesys.FileSystem, t *trace.Trace) error {
+	res, err := o.explain.encode(t)
+	if err != nil {
+		return err
+	}
+	if o.outputPath != "" && !fSys.IsDir(o.outputPath) {
+		return fSys.WriteFile(o.outputPath, res)
+	}
+	_, err = out.Write(res)
+	return err
+}
+
 func NewCmdBuildPrune(
 	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
 	rf *resmap.Factory, ptf resmap.PatchFactory,
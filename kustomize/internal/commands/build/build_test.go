@@ -4,9 +4,28 @@
 package build
 
 import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/profile"
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/testutils/resmaptest"
+	"sigs.k8s.io/kustomize/v3/api/testutils/valtest"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/transformer"
 	"sigs.k8s.io/kustomize/v3/pkg/pgmconfig"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
 )
 
 func TestNewOptionsToSilenceCodeInspectionError(t *testing.T) {
@@ -51,3 +70,733 @@ func TestBuildValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildValidateAllVariants(t *testing.T) {
+	opts := Options{allVariants: true}
+	err := opts.Validate(nil)
+	if err == nil || !strings.Contains(err.Error(), "requires --output") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	opts = Options{allVariants: true, outputPath: "out"}
+	err = opts.Validate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateFlagsOverrides(t *testing.T) {
+	defer func() {
+		flagSetImageValue = nil
+		flagNamespaceValue = ""
+		flagLabelValue = nil
+	}()
+	k, err := validateFlagsOverrides()
+	if err != nil || k != nil {
+		t.Fatalf("expected no overrides, got %v, %v", k, err)
+	}
+	flagSetImageValue = []string{"nginx=nginx:1.7", "redis@sha256:abc"}
+	flagNamespaceValue = "ci"
+	flagLabelValue = []string{"env:ci,team:web", "build:12"}
+	k, err = validateFlagsOverrides()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &types.Kustomization{
+		TypeMeta: types.TypeMeta{
+			APIVersion: types.KustomizationVersion,
+			Kind:       types.KustomizationKind,
+		},
+		Namespace: "ci",
+		Images: []types.Image{
+			{Name: "nginx", NewName: "nginx", NewTag: "1.7"},
+			{Name: "redis", Digest: "sha256:abc"},
+		},
+		CommonLabels: map[string]string{
+			"env": "ci", "team": "web", "build": "12"},
+	}
+	if !reflect.DeepEqual(k, expected) {
+		t.Fatalf("expected %v, got %v", expected, k)
+	}
+	flagSetImageValue = []string{"nginx"}
+	if _, err = validateFlagsOverrides(); err == nil {
+		t.Fatalf("expected error for illegal image")
+	}
+}
+
+func TestReadFlagsVars(t *testing.T) {
+	defer func() {
+		flagVarValue = nil
+		flagVarFileValue = nil
+	}()
+	fSys := filesys.MakeFsInMemory()
+	v := valtest_test.MakeFakeValidator()
+	values, err := readFlagsVars(fSys, v)
+	if err != nil || values != nil {
+		t.Fatalf("expected no vars, got %v, %v", values, err)
+	}
+	fSys.WriteFile("/ci/vars.env", []byte("# build\nBUILD=17\nGIT_SHA=fromFile\n"))
+	flagVarFileValue = []string{"/ci/vars.env"}
+	flagVarValue = []string{"GIT_SHA=0a1b2c"}
+	values, err = readFlagsVars(fSys, v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"BUILD": "17", "GIT_SHA": "0a1b2c"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %v, got %v", expected, values)
+	}
+	flagVarFileValue = []string{"/ci/missing.env"}
+	if _, err = readFlagsVars(fSys, v); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	testCases := map[string]struct {
+		arg      string
+		expected types.Selector
+		erMsg    string
+	}{
+		"kind": {
+			arg: "kind=CustomResourceDefinition",
+			expected: types.Selector{
+				Gvk: resid.Gvk{Kind: "CustomResourceDefinition"}},
+		},
+		"labelSelectorWithCommas": {
+			arg: "namespace=prod,labelSelector=app=web,tier in (a,b),name=web-.*",
+			expected: types.Selector{
+				Namespace:     "prod",
+				Name:          "web-.*",
+				LabelSelector: "app=web,tier in (a,b)",
+			},
+		},
+		"unknownField": {
+			arg:   "colour=red",
+			erMsg: "'colour=red' is not a field=value pair of a known field",
+		},
+		"badName": {
+			arg:   "name=(",
+			erMsg: "missing closing )",
+		},
+	}
+	for n, tc := range testCases {
+		s, err := parseSelector(tc.arg)
+		if tc.erMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.erMsg) {
+				t.Fatalf("%s: expected error '%s', got %v", n, tc.erMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", n, err)
+		}
+		if !reflect.DeepEqual(s, tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", n, tc.expected, s)
+		}
+	}
+}
+
+func TestFilterResources(t *testing.T) {
+	rf := resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl())
+	makeResMap := func() resmap.ResMap {
+		return resmaptest_test.NewRmBuilder(t, rf).
+			Add(map[string]interface{}{
+				"apiVersion": "apiextensions.k8s.io/v1beta1",
+				"kind":       "CustomResourceDefinition",
+				"metadata":   map[string]interface{}{"name": "crontabs"},
+			}).
+			Add(map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "web",
+					"namespace": "prod",
+					"labels":    map[string]interface{}{"tier": "front"},
+				},
+			}).
+			Add(map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "db",
+					"namespace": "prod",
+					"labels":    map[string]interface{}{"tier": "back"},
+				},
+			}).ResMap()
+	}
+	names := func(m resmap.ResMap) (result []string) {
+		for _, r := range m.Resources() {
+			result = append(result, r.GetName())
+		}
+		return result
+	}
+	testCases := map[string]struct {
+		selects  []types.Selector
+		excludes []types.Selector
+		expected []string
+	}{
+		"none": {
+			expected: []string{"crontabs", "web", "db"},
+		},
+		"crds": {
+			selects: []types.Selector{
+				{Gvk: resid.Gvk{Kind: "CustomResourceDefinition"}}},
+			expected: []string{"crontabs"},
+		},
+		"noCrds": {
+			excludes: []types.Selector{
+				{Gvk: resid.Gvk{Kind: "CustomResourceDefinition"}}},
+			expected: []string{"web", "db"},
+		},
+		"selectThenExclude": {
+			selects: []types.Selector{
+				{Gvk: resid.Gvk{Kind: "Deployment"}},
+				{Name: "crontabs"}},
+			excludes: []types.Selector{
+				{LabelSelector: "tier=back"}, {Name: "d.*"}},
+			expected: []string{"crontabs", "web"},
+		},
+		"nothing": {
+			selects:  []types.Selector{{Name: "missing"}},
+			expected: nil,
+		},
+	}
+	for n, tc := range testCases {
+		m := makeResMap()
+		err := filterResources(m, tc.selects, tc.excludes)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", n, err)
+		}
+		if !reflect.DeepEqual(names(m), tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", n, tc.expected, names(m))
+		}
+	}
+}
+
+func TestValidateFlagOutputFormat(t *testing.T) {
+	defer func() { flagOutputFormatValue = yamlFormat.String() }()
+	for v, expected := range map[string]outputFormat{
+		"yaml":  yamlFormat,
+		"json":  jsonFormat,
+		"jsonl": jsonLinesFormat,
+		"list":  listFormat,
+	} {
+		flagOutputFormatValue = v
+		f, err := validateFlagOutputFormat()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", v, err)
+		}
+		if f != expected {
+			t.Fatalf("expected %v, got %v", expected, f)
+		}
+	}
+	flagOutputFormatValue = "xml"
+	if _, err := validateFlagOutputFormat(); err == nil {
+		t.Fatalf("expected error for illegal format")
+	}
+}
+
+func TestValidateFlagOutputLayout(t *testing.T) {
+	defer func() { flagOutputLayoutValue = "" }()
+	for v, expected := range map[string]outputLayout{
+		"":          unspecifiedLayout,
+		"flat":      flatLayout,
+		"namespace": namespaceLayout,
+		"kind":      kindLayout,
+		"phase":     phaseLayout,
+	} {
+		flagOutputLayoutValue = v
+		l, err := validateFlagOutputLayout()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", v, err)
+		}
+		if l != expected {
+			t.Fatalf("expected %v, got %v", expected, l)
+		}
+	}
+	flagOutputLayoutValue = "tree"
+	if _, err := validateFlagOutputLayout(); err == nil {
+		t.Fatalf("expected error for illegal layout")
+	}
+	flagOutputLayoutValue = "flat"
+	opts := Options{}
+	err := opts.Validate(nil)
+	if err == nil || !strings.Contains(err.Error(), "requires --output") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
+
+func TestOutputLayoutWrite(t *testing.T) {
+	rf := resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl())
+	m := resmaptest_test.NewRmBuilder(t, rf).
+		Add(map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1beta1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": "crontabs"},
+		}).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]interface{}{"name": "prod"},
+		}).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name": "web", "namespace": "prod"},
+		}).
+		Add(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name": "web", "namespace": "test"},
+		}).ResMap()
+	testCases := map[outputLayout][]string{
+		flatLayout: {
+			"apiextensions.k8s.io_v1beta1_customresourcedefinition_crontabs.yaml",
+			"~g_v1_namespace_prod.yaml",
+			"prod_~g_v1_service_web.yaml",
+			"test_~g_v1_service_web.yaml",
+		},
+		namespaceLayout: {
+			"_cluster/apiextensions.k8s.io_v1beta1_customresourcedefinition_crontabs.yaml",
+			"_cluster/~g_v1_namespace_prod.yaml",
+			"prod/~g_v1_service_web.yaml",
+			"test/~g_v1_service_web.yaml",
+		},
+		kindLayout: {
+			"customresourcedefinition/apiextensions.k8s.io_v1beta1_customresourcedefinition_crontabs.yaml",
+			"namespace/~g_v1_namespace_prod.yaml",
+			"service/prod_~g_v1_service_web.yaml",
+			"service/test_~g_v1_service_web.yaml",
+		},
+		phaseLayout: {
+			"00-crds/apiextensions.k8s.io_v1beta1_customresourcedefinition_crontabs.yaml",
+			"10-namespaces/~g_v1_namespace_prod.yaml",
+			"20-resources/prod_~g_v1_service_web.yaml",
+			"20-resources/test_~g_v1_service_web.yaml",
+		},
+	}
+	for l, files := range testCases {
+		fSys := filesys.MakeFsInMemory()
+		err := l.write(fSys, "/out", m)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", l, err)
+		}
+		for _, f := range files {
+			if !fSys.Exists("/out/" + f) {
+				t.Fatalf("%s: expected file %s", l, f)
+			}
+		}
+		k, err := fSys.ReadFile("/out/kustomization.yaml")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", l, err)
+		}
+		expected := "apiVersion: " + types.KustomizationVersion + "\n" +
+			"kind: Kustomization\nresources:\n- " +
+			strings.Join(files, "\n- ") + "\n"
+		if string(k) != expected {
+			t.Fatalf("%s: expected\n%s\ngot\n%s", l, expected, k)
+		}
+	}
+}
+
+func TestValidateFlagsWatch(t *testing.T) {
+	opts := Options{watchDiff: true}
+	err := opts.validateFlagsWatch()
+	if err == nil || !strings.Contains(err.Error(), "requires --watch") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	opts = Options{watch: true, allVariants: true}
+	if err = opts.validateFlagsWatch(); err == nil {
+		t.Fatalf("expected error")
+	}
+	opts = Options{watch: true, watchDiff: true, explain: noExplain}
+	if err = opts.validateFlagsWatch(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// syncBuffer is a buffer that can be written
+// and read from different goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// lockedFs is a file system that can be written in one
+// goroutine while it's read in another, as fsInMemory can't.
+type lockedFs struct {
+	mu sync.Mutex
+	filesys.FileSystem
+}
+
+func (fs *lockedFs) Mkdir(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.Mkdir(path)
+}
+
+func (fs *lockedFs) MkdirAll(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.MkdirAll(path)
+}
+
+func (fs *lockedFs) RemoveAll(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.RemoveAll(path)
+}
+
+func (fs *lockedFs) IsDir(path string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.IsDir(path)
+}
+
+func (fs *lockedFs) CleanedAbs(path string) (filesys.ConfirmedDir, string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.CleanedAbs(path)
+}
+
+func (fs *lockedFs) Exists(path string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.Exists(path)
+}
+
+func (fs *lockedFs) Glob(pattern string) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.Glob(pattern)
+}
+
+func (fs *lockedFs) ReadFile(path string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.ReadFile(path)
+}
+
+func (fs *lockedFs) WriteFile(path string, data []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.WriteFile(path, data)
+}
+
+func (fs *lockedFs) Walk(path string, walkFn filepath.WalkFunc) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.FileSystem.Walk(path, walkFn)
+}
+
+func TestRunWatch(t *testing.T) {
+	defer func(i, d time.Duration) {
+		watchInterval, watchDebounce = i, d
+	}(watchInterval, watchDebounce)
+	watchInterval, watchDebounce = 5*time.Millisecond, 5*time.Millisecond
+
+	fSys := &lockedFs{FileSystem: filesys.MakeFsInMemory()}
+	fSys.WriteFile("/app/overlay/kustomization.yaml", []byte(`
+resources:
+- ../base
+`))
+	fSys.WriteFile("/app/base/kustomization.yaml", []byte(`
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("/app/base/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	pl := plugins.NewLoader(plugins.DefaultPluginConfig(), rf)
+	opts := Options{
+		kustomizationPath: "/app/overlay",
+		loadRestrictor:    loader.RestrictionRootOnly,
+		outOrder:          legacy,
+		outFormat:         yamlFormat,
+		explain:           noExplain,
+		watch:             true,
+		watchDiff:         true,
+	}
+	var out syncBuffer
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- opts.runWatch(
+			&out, valtest_test.MakeFakeValidator(), fSys,
+			rf, transformer.NewFactoryImpl(), pl, stop)
+	}()
+	waitFor := func(s string) {
+		for i := 0; !strings.Contains(out.String(), s); i++ {
+			if i == 400 {
+				t.Fatalf("expected output containing '%s', got '%s'", s, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor("name: web")
+
+	// A change to a file read by a base.
+	fSys.WriteFile("/app/base/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels:
+    app: web
+`))
+	waitFor(`metadata/labels/app: <none> -> "web"`)
+
+	// A file added to a kustomization directory, then named
+	// in the kustomization; a build failing in between
+	// doesn't stop the watch.
+	fSys.WriteFile("/app/overlay/kustomization.yaml", []byte(`
+resources:
+- ../base
+- pod.yaml
+`))
+	fSys.WriteFile("/app/overlay/pod.yaml", []byte(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+`))
+	waitFor("only in right: ~G_v1_Pod|~X|pod")
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateFlagReorderOutput(t *testing.T) {
+	defer func() { flagReorderOutputValue = legacy.String() }()
+	flagReorderOutputValue = "kind"
+	r, err := validateFlagReorderOutput()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r != kind {
+		t.Fatalf("expected %v, got %v", kind, r)
+	}
+	flagReorderOutputValue = "random"
+	if _, err := validateFlagReorderOutput(); err == nil {
+		t.Fatalf("expected error for illegal reordering")
+	}
+}
+
+func TestValidateFlagExplain(t *testing.T) {
+	defer func() { flagExplainValue = noExplain.String() }()
+	for v, expected := range map[string]explainMode{
+		"none": noExplain,
+		"text": textExplain,
+		"json": jsonExplain,
+	} {
+		flagExplainValue = v
+		e, err := validateFlagExplain()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", v, err)
+		}
+		if e != expected {
+			t.Fatalf("expected %v, got %v", expected, e)
+		}
+	}
+	flagExplainValue = "html"
+	if _, err := validateFlagExplain(); err == nil {
+		t.Fatalf("expected error for illegal explain mode")
+	}
+}
+
+func TestValidateFlagProfile(t *testing.T) {
+	defer func() { flagProfileValue = noProfile.String() }()
+	for v, expected := range map[string]profileMode{
+		"none":  noProfile,
+		"table": tableProfile,
+		"json":  jsonProfile,
+	} {
+		flagProfileValue = v
+		p, err := validateFlagProfile()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", v, err)
+		}
+		if p != expected {
+			t.Fatalf("expected %v, got %v", expected, p)
+		}
+	}
+	flagProfileValue = "html"
+	if _, err := validateFlagProfile(); err == nil {
+		t.Fatalf("expected error for illegal profile mode")
+	}
+}
+
+func TestRunBuildProfile(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+namePrefix: p-
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("/app/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	var out, profileOut bytes.Buffer
+	opts := Options{
+		kustomizationPath: "/app",
+		loadRestrictor:    loader.RestrictionRootOnly,
+		outOrder:          legacy,
+		outFormat:         yamlFormat,
+		explain:           noExplain,
+		profile:           jsonProfile,
+		profileOut:        &profileOut,
+	}
+	err := opts.RunBuild(
+		&out, valtest_test.MakeFakeValidator(), fSys, rf,
+		transformer.NewFactoryImpl(),
+		plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "name: p-web") {
+		t.Fatalf("unexpected output\n%s", out.String())
+	}
+	var p struct {
+		Stages []profile.Stage `json:"stages"`
+	}
+	err = json.Unmarshal(profileOut.Bytes(), &p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, profileOut.String())
+	}
+	stages := map[string]bool{}
+	for _, s := range p.Stages {
+		stages[s.Stage] = true
+	}
+	for _, s := range []string{
+		"accumulate", "transformer PrefixSuffixTransformer",
+		"hash", "FixBackReferences", "ResolveVars"} {
+		if !stages[s] {
+			t.Fatalf("expected stage '%s' in\n%s", s, profileOut.String())
+		}
+	}
+}
+
+func TestRunBuildSortOptions(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+resources:
+- resources.yaml
+sortOptions:
+  kindPriority:
+  - Deployment
+`))
+	fSys.WriteFile("/app/resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	var out bytes.Buffer
+	opts := Options{
+		kustomizationPath: "/app",
+		loadRestrictor:    loader.RestrictionRootOnly,
+		outOrder:          legacy,
+		outFormat:         yamlFormat,
+		explain:           noExplain,
+	}
+	err := opts.RunBuild(
+		&out, valtest_test.MakeFakeValidator(), fSys, rf,
+		transformer.NewFactoryImpl(),
+		plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "apiVersion: apps/v1\nkind: Deployment") {
+		t.Fatalf("expected the kustomization's order\n%s", out.String())
+	}
+	// E.g. for the next build of a watch.
+	if opts.outOrder != legacy {
+		t.Fatalf("expected the options to be left as they were")
+	}
+}
+
+func TestRunBuildAllVariantsSortOptions(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+resources:
+- resources.yaml
+sortOptions:
+  kindPriority:
+  - Deployment
+variants:
+- name: dev
+- name: prod
+  namePrefix: prod-
+`))
+	fSys.WriteFile("/app/resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	opts := Options{
+		kustomizationPath: "/app",
+		outputPath:        "/out",
+		allVariants:       true,
+		loadRestrictor:    loader.RestrictionRootOnly,
+		outOrder:          legacy,
+		outLayout:         flatLayout,
+		outFormat:         yamlFormat,
+		explain:           noExplain,
+	}
+	err := opts.RunBuild(
+		nil, valtest_test.MakeFakeValidator(), fSys, rf,
+		transformer.NewFactoryImpl(),
+		plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, v := range []string{"dev", "prod"} {
+		content, err := fSys.ReadFile("/out/" + v + "/kustomization.yaml")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(content), "resources:\n- apps_v1_deployment_") {
+			t.Fatalf("%s: expected the kustomization's order\n%s", v, content)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/trace"
+)
+
+//go:generate stringer -type=explainMode -linecomment
+type explainMode int
+
+const (
+	unknownExplain explainMode = iota // unknown
+	noExplain                         // none
+	textExplain                       // text
+	jsonExplain                       // json
+)
+
+const (
+	flagExplainName = "explain"
+)
+
+var (
+	flagExplainValue = noExplain.String()
+	flagExplainHelp  = "Instead of the build output, print the changes " +
+		"each transformer made to each resource. " +
+		"Use '" + textExplain.String() + "' (the default if no value is given) " +
+		"for a human-readable report, or '" + jsonExplain.String() + "' for JSON."
+)
+
+func addFlagExplain(set *pflag.FlagSet) {
+	set.StringVar(
+		&flagExplainValue, flagExplainName,
+		noExplain.String(), flagExplainHelp)
+	set.Lookup(flagExplainName).NoOptDefVal = textExplain.String()
+}
+
+func validateFlagExplain() (explainMode, error) {
+	for _, e := range []explainMode{
+		noExplain, textExplain, jsonExplain} {
+		if flagExplainValue == e.String() {
+			return e, nil
+		}
+	}
+	return unknownExplain, fmt.Errorf(
+		"illegal flag value --%s %s; legal values: %v",
+		flagExplainName, flagExplainValue,
+		[]string{
+			noExplain.String(), textExplain.String(), jsonExplain.String()})
+}
+
+// encode renders the trace in the given mode.
+func (e explainMode) encode(t *trace.Trace) ([]byte, error) {
+	if e == jsonExplain {
+		return t.AsJson()
+	}
+	return t.Report(), nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+)
+
+//go:generate stringer -type=outputFormat -linecomment
+type outputFormat int
+
+const (
+	unknownFormat   outputFormat = iota // unknown
+	yamlFormat                          // yaml
+	jsonFormat                          // json
+	jsonLinesFormat                     // jsonl
+	listFormat                          // list
+)
+
+const (
+	flagOutputFormatName = "output-format"
+)
+
+var (
+	flagOutputFormatValue = yamlFormat.String()
+	flagOutputFormatHelp  = "Format of the build output. " +
+		"Use '" + yamlFormat.String() + "' for a multi-document YAML stream, " +
+		"'" + jsonFormat.String() + "' for one JSON array, " +
+		"'" + jsonLinesFormat.String() + "' for one JSON object per line, or " +
+		"'" + listFormat.String() + "' for a single v1/List object."
+)
+
+func addFlagOutputFormat(set *pflag.FlagSet) {
+	set.StringVar(
+		&flagOutputFormatValue, flagOutputFormatName,
+		yamlFormat.String(), flagOutputFormatHelp)
+}
+
+func validateFlagOutputFormat() (outputFormat, error) {
+	for _, f := range []outputFormat{
+		yamlFormat, jsonFormat, jsonLinesFormat, listFormat} {
+		if flagOutputFormatValue == f.String() {
+			return f, nil
+		}
+	}
+	return unknownFormat, fmt.Errorf(
+		"illegal flag value --%s %s; legal values: %v",
+		flagOutputFormatName, flagOutputFormatValue,
+		[]string{
+			yamlFormat.String(), jsonFormat.String(),
+			jsonLinesFormat.String(), listFormat.String()})
+}
+
+// encode renders the ResMap in the given format.
+func (f outputFormat) encode(m resmap.ResMap) ([]byte, error) {
+	switch f {
+	case jsonFormat:
+		return m.AsJson()
+	case jsonLinesFormat:
+		return m.AsJsonLines()
+	case listFormat:
+		return m.AsList()
+	default:
+		return m.AsYaml()
+	}
+}
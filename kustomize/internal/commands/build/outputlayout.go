@@ -0,0 +1,162 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/pkg/pgmconfig"
+	"sigs.k8s.io/yaml"
+)
+
+//go:generate stringer -type=outputLayout -linecomment
+type outputLayout int
+
+const (
+	unspecifiedLayout outputLayout = iota // unspecified
+	flatLayout                            // flat
+	namespaceLayout                       // namespace
+	kindLayout                            // kind
+	phaseLayout                           // phase
+)
+
+const (
+	flagOutputLayoutName = "output-layout"
+)
+
+// The directories of the phase layout, in the order
+// in which their resources should be applied.
+const (
+	phaseCrds       = "00-crds"
+	phaseNamespaces = "10-namespaces"
+	phaseResources  = "20-resources"
+	phaseWebhooks   = "30-webhooks"
+)
+
+// clusterDir holds the cluster scoped resources in the
+// namespace layout.  It cannot be a namespace's name.
+const clusterDir = "_cluster"
+
+var (
+	flagOutputLayoutValue string
+	flagOutputLayoutHelp  = "Layout of the files written to the --output directory, " +
+		"one per resource.  Use '" + flatLayout.String() + "' to write them all " +
+		"to the directory, '" + namespaceLayout.String() + "' for a subdirectory " +
+		"per namespace, '" + kindLayout.String() + "' for a subdirectory per kind, " +
+		"or '" + phaseLayout.String() + "' for subdirectories in apply order (" +
+		strings.Join([]string{
+			phaseCrds, phaseNamespaces, phaseResources, phaseWebhooks}, ", ") +
+		").  Each layout adds a " + pgmconfig.DefaultKustomizationFileName() +
+		" listing the files in build order.  If unset, files are written " +
+		"to the directory without one."
+)
+
+func addFlagOutputLayout(set *pflag.FlagSet) {
+	set.StringVar(
+		&flagOutputLayoutValue, flagOutputLayoutName,
+		"", flagOutputLayoutHelp)
+}
+
+func validateFlagOutputLayout() (outputLayout, error) {
+	if flagOutputLayoutValue == "" {
+		return unspecifiedLayout, nil
+	}
+	for _, l := range []outputLayout{
+		flatLayout, namespaceLayout, kindLayout, phaseLayout} {
+		if flagOutputLayoutValue == l.String() {
+			return l, nil
+		}
+	}
+	return unspecifiedLayout, fmt.Errorf(
+		"illegal flag value --%s %s; legal values: %v",
+		flagOutputLayoutName, flagOutputLayoutValue,
+		[]string{
+			flatLayout.String(), namespaceLayout.String(),
+			kindLayout.String(), phaseLayout.String()})
+}
+
+// dir returns the directory, relative to the
+// output directory, of the resource's file.
+func (l outputLayout) dir(res *resource.Resource) string {
+	switch l {
+	case namespaceLayout:
+		ns := res.CurId().EffectiveNamespace()
+		if ns == resid.TotallyNotANamespace {
+			return clusterDir
+		}
+		return ns
+	case kindLayout:
+		return strings.ToLower(res.GetKind())
+	case phaseLayout:
+		switch res.GetKind() {
+		case "CustomResourceDefinition":
+			return phaseCrds
+		case "Namespace":
+			return phaseNamespaces
+		case "MutatingWebhookConfiguration",
+			"ValidatingWebhookConfiguration":
+			return phaseWebhooks
+		default:
+			return phaseResources
+		}
+	default:
+		return ""
+	}
+}
+
+// write writes each resource to its own file, in the
+// directory the layout picks for it, and a kustomization
+// listing the files in the order of the resources, so
+// that the directory can itself be built or applied.
+func (l outputLayout) write(
+	fSys filesys.FileSystem, dir string, m resmap.ResMap) error {
+	if fSys.Exists(dir) && !fSys.IsDir(dir) {
+		return fmt.Errorf("'%s' is not a directory", dir)
+	}
+	// Unless each namespace has its own directory, the
+	// files of resources in different namespaces are
+	// told apart by the namespace, as in writeIndividualFiles.
+	prefixNamespace := l != namespaceLayout &&
+		len(m.GroupedByCurrentNamespace()) > 1
+	written := map[string]*resource.Resource{}
+	k := &types.Kustomization{}
+	for _, res := range m.Resources() {
+		fName := fileName(res)
+		ns := res.CurId().EffectiveNamespace()
+		if prefixNamespace && ns != resid.TotallyNotANamespace {
+			fName = strings.ToLower(ns) + "_" + fName
+		}
+		path := filepath.Join(l.dir(res), fName)
+		if other, ok := written[path]; ok {
+			return fmt.Errorf(
+				"resources %s and %s would both be written to '%s'",
+				other.CurId(), res.CurId(), path)
+		}
+		written[path] = res
+		err := fSys.MkdirAll(filepath.Join(dir, l.dir(res)))
+		if err != nil {
+			return err
+		}
+		err = writeFile(fSys, dir, path, res)
+		if err != nil {
+			return err
+		}
+		k.Resources = append(k.Resources, filepath.ToSlash(path))
+	}
+	k.FixKustomizationPostUnmarshalling()
+	out, err := yaml.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(
+		filepath.Join(dir, pgmconfig.DefaultKustomizationFileName()), out)
+}
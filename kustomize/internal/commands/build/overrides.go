@@ -0,0 +1,99 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/set"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/util"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+const (
+	flagSetImageName   = "set-image"
+	flagNamespaceName  = "namespace"
+	flagNamePrefixName = "name-prefix"
+	flagLabelName      = "label"
+	flagAnnotationName = "annotation"
+)
+
+var (
+	flagSetImageValue   []string
+	flagNamespaceValue  string
+	flagNamePrefixValue string
+	flagLabelValue      []string
+	flagAnnotationValue []string
+)
+
+// addFlagsOverrides adds the flags that customize the build
+// output as an overlay of the kustomization would, without
+// editing any file.
+func addFlagsOverrides(set *pflag.FlagSet) {
+	set.StringArrayVar(
+		&flagSetImageValue, flagSetImageName, nil,
+		"Override an image, as 'kustomize edit set image' would, "+
+			"e.g. nginx=nginx:1.7.  May be repeated.")
+	set.StringVar(
+		&flagNamespaceValue, flagNamespaceName, "",
+		"Set the namespace of all resources.")
+	set.StringVar(
+		&flagNamePrefixValue, flagNamePrefixName, "",
+		"Prepend a prefix to the names of all resources.")
+	set.StringArrayVar(
+		&flagLabelValue, flagLabelName, nil,
+		"Add labels, e.g. env:prod,team:web, to all resources and "+
+			"selectors.  May be repeated.")
+	set.StringArrayVar(
+		&flagAnnotationValue, flagAnnotationName, nil,
+		"Add annotations, e.g. build:1234, to all resources.  May be repeated.")
+}
+
+// validateFlagsOverrides returns a kustomization holding
+// the overrides, or nil if there are none.
+func validateFlagsOverrides() (*types.Kustomization, error) {
+	k := &types.Kustomization{
+		Namespace:  flagNamespaceValue,
+		NamePrefix: flagNamePrefixValue,
+	}
+	for _, arg := range flagSetImageValue {
+		img, err := set.ParseImage(arg)
+		if err != nil {
+			return nil, err
+		}
+		k.Images = append(k.Images, img)
+	}
+	var err error
+	k.CommonLabels, err = convertToMap(flagLabelValue, flagLabelName)
+	if err != nil {
+		return nil, err
+	}
+	k.CommonAnnotations, err = convertToMap(
+		flagAnnotationValue, flagAnnotationName)
+	if err != nil {
+		return nil, err
+	}
+	if k.Namespace == "" && k.NamePrefix == "" && len(k.Images) == 0 &&
+		len(k.CommonLabels) == 0 && len(k.CommonAnnotations) == 0 {
+		return nil, nil
+	}
+	k.FixKustomizationPostUnmarshalling()
+	return k, nil
+}
+
+func convertToMap(args []string, kind string) (map[string]string, error) {
+	var result map[string]string
+	for _, arg := range args {
+		m, err := util.ConvertToMap(arg, kind)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			if result == nil {
+				result = map[string]string{}
+			}
+			result[k] = v
+		}
+	}
+	return result, nil
+}
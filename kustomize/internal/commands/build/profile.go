@@ -0,0 +1,86 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/profile"
+	"sigs.k8s.io/kustomize/v3/pkg/target"
+)
+
+//go:generate stringer -type=profileMode -linecomment
+type profileMode int
+
+const (
+	unknownProfile profileMode = iota // unknown
+	noProfile                         // none
+	tableProfile                      // table
+	jsonProfile                       // json
+)
+
+const (
+	flagProfileName = "profile"
+)
+
+var (
+	flagProfileValue = noProfile.String()
+	flagProfileHelp  = "After the build output, print to stderr the wall time " +
+		"and allocations of each stage of the build, the slowest first. " +
+		"Use '" + tableProfile.String() + "' (the default if no value is given) " +
+		"for a table, or '" + jsonProfile.String() + "' for JSON."
+)
+
+func addFlagProfile(set *pflag.FlagSet) {
+	set.StringVar(
+		&flagProfileValue, flagProfileName,
+		noProfile.String(), flagProfileHelp)
+	set.Lookup(flagProfileName).NoOptDefVal = tableProfile.String()
+}
+
+func validateFlagProfile() (profileMode, error) {
+	for _, p := range []profileMode{
+		noProfile, tableProfile, jsonProfile} {
+		if flagProfileValue == p.String() {
+			return p, nil
+		}
+	}
+	return unknownProfile, fmt.Errorf(
+		"illegal flag value --%s %s; legal values: %v",
+		flagProfileName, flagProfileValue,
+		[]string{
+			noProfile.String(), tableProfile.String(), jsonProfile.String()})
+}
+
+// start returns a new profile, set to record the stages
+// of the target's build, or nil if there's no profiling.
+func (p profileMode) start(kt *target.KustTarget) *profile.Profile {
+	if p == noProfile || p == unknownProfile {
+		return nil
+	}
+	result := profile.New()
+	kt.SetProfile(result)
+	return result
+}
+
+// emit writes the profile, if any, in the given mode.
+func (p profileMode) emit(out io.Writer, pr *profile.Profile) error {
+	if pr == nil {
+		return nil
+	}
+	var res []byte
+	if p == jsonProfile {
+		var err error
+		res, err = pr.AsJson()
+		if err != nil {
+			return err
+		}
+	} else {
+		res = pr.Report()
+	}
+	_, err := out.Write(res)
+	return err
+}
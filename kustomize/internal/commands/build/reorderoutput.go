@@ -7,6 +7,10 @@ import (
 	"fmt"
 
 	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/plugin/builtin"
+	"sigs.k8s.io/yaml"
 )
 
 //go:generate stringer -type=reorderOutput
@@ -16,23 +20,34 @@ const (
 	unspecified reorderOutput = iota
 	none
 	legacy
+	kind
 )
 
 const (
 	flagReorderOutputName = "reorder"
+	flagKindPriorityName  = "kind-priority"
 )
 
 var (
 	flagReorderOutputValue = legacy.String()
 	flagReorderOutputHelp  = "Reorder the resources just before output. " +
 		"Use '" + legacy.String() + "' to apply a legacy reordering (Namespaces first, Webhooks last, etc). " +
-		"Use '" + none.String() + "' to suppress a final reordering."
+		"Use '" + kind.String() + "' to order by the kinds given in --" + flagKindPriorityName +
+		" (Namespaces and CRDs still first, Webhooks still last). " +
+		"Use '" + none.String() + "' to suppress a final reordering. " +
+		"If unset, a kustomization specifying sortOptions is not reordered."
+	flagKindPriorityValue []string
+	flagKindPriorityHelp  = "Comma separated list of kinds, in the order " +
+		"they should appear when using --" + flagReorderOutputName + " " + kind.String() + "."
 )
 
 func addFlagReorderOutput(set *pflag.FlagSet) {
 	set.StringVar(
 		&flagReorderOutputValue, flagReorderOutputName,
 		legacy.String(), flagReorderOutputHelp)
+	set.StringSliceVar(
+		&flagKindPriorityValue, flagKindPriorityName,
+		nil, flagKindPriorityHelp)
 }
 
 func validateFlagReorderOutput() (reorderOutput, error) {
@@ -41,10 +56,37 @@ func validateFlagReorderOutput() (reorderOutput, error) {
 		return none, nil
 	case legacy.String():
 		return legacy, nil
+	case kind.String():
+		return kind, nil
 	default:
 		return unspecified, fmt.Errorf(
 			"illegal flag value --%s %s; legal values: %v",
 			flagReorderOutputName, flagReorderOutputValue,
-			[]string{legacy.String(), none.String()})
+			[]string{legacy.String(), kind.String(), none.String()})
+	}
+}
+
+// reorder sorts the ResMap per the given reordering.
+func (r reorderOutput) reorder(m resmap.ResMap) error {
+	switch r {
+	case legacy:
+		// Done this way just to show how overall sorting
+		// can be performed by a plugin.  This particular
+		// plugin doesn't require configuration; just make
+		// it and call transform.
+		return builtin.NewLegacyOrderTransformerPlugin().Transform(m)
+	case kind:
+		c, err := yaml.Marshal(
+			types.SortOptions{KindPriority: flagKindPriorityValue})
+		if err != nil {
+			return err
+		}
+		p := builtin.NewSortOrderTransformerPlugin()
+		if err = p.Config(nil, c); err != nil {
+			return err
+		}
+		return p.Transform(m)
+	default:
+		return nil
 	}
 }
@@ -0,0 +1,156 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+const (
+	flagSelectName  = "select"
+	flagExcludeName = "exclude"
+)
+
+var (
+	flagSelectValue  []string
+	flagExcludeValue []string
+)
+
+// addFlagsSelectors adds the flags that filter
+// the resources of the build output.
+func addFlagsSelectors(set *pflag.FlagSet) {
+	set.StringArrayVar(
+		&flagSelectValue, flagSelectName, nil,
+		"Output only the resources that this selector picks, e.g. "+
+			"kind=CustomResourceDefinition or namespace=prod,labelSelector=app=web.  "+
+			"Fields: group, version, kind, name, namespace, labelSelector and "+
+			"annotationSelector.  May be repeated, to output what any of them picks.")
+	set.StringArrayVar(
+		&flagExcludeValue, flagExcludeName, nil,
+		"Omit from the output the resources that this selector picks, "+
+			"in the syntax of --"+flagSelectName+".  May be repeated.")
+}
+
+// validateFlagsSelectors returns the selectors
+// given by the --select and --exclude flags.
+func validateFlagsSelectors() (
+	selects []types.Selector, excludes []types.Selector, err error) {
+	selects, err = parseSelectors(flagSelectValue, flagSelectName)
+	if err != nil {
+		return nil, nil, err
+	}
+	excludes, err = parseSelectors(flagExcludeValue, flagExcludeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return selects, excludes, nil
+}
+
+func parseSelectors(args []string, flag string) ([]types.Selector, error) {
+	var result []types.Selector
+	for _, arg := range args {
+		s, err := parseSelector(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s '%s': %v", flag, arg, err)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// parseSelector parses a comma separated list of
+// field=value pairs.  A label or annotation selector
+// may hold commas of its own, so text after a comma
+// that doesn't start a pair with a known field
+// continues the preceding value.
+func parseSelector(arg string) (types.Selector, error) {
+	var s types.Selector
+	fields := map[string]*string{
+		"group":              &s.Group,
+		"version":            &s.Version,
+		"kind":               &s.Kind,
+		"name":               &s.Name,
+		"namespace":          &s.Namespace,
+		"labelSelector":      &s.LabelSelector,
+		"annotationSelector": &s.AnnotationSelector,
+	}
+	var value *string
+	for _, part := range strings.Split(arg, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && fields[kv[0]] != nil {
+			value = fields[kv[0]]
+			*value = kv[1]
+			continue
+		}
+		if value == nil {
+			return s, fmt.Errorf(
+				"'%s' is not a field=value pair of a known field", part)
+		}
+		*value += "," + part
+	}
+	for _, pattern := range []string{s.Name, s.Namespace} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// filterResources removes the resources that no
+// select picks, if there are any selects, and the
+// resources that any exclude picks.
+func filterResources(
+	m resmap.ResMap, selects, excludes []types.Selector) error {
+	drop := map[*resource.Resource]bool{}
+	if len(selects) > 0 {
+		picked, err := pickResources(m, selects)
+		if err != nil {
+			return err
+		}
+		for _, r := range m.Resources() {
+			drop[r] = !picked[r]
+		}
+	}
+	picked, err := pickResources(m, excludes)
+	if err != nil {
+		return err
+	}
+	for r := range picked {
+		drop[r] = true
+	}
+	for _, r := range m.Resources() {
+		if drop[r] {
+			err = m.Remove(r.CurId())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pickResources returns the resources
+// that any of the selectors picks.
+func pickResources(
+	m resmap.ResMap,
+	selectors []types.Selector) (map[*resource.Resource]bool, error) {
+	result := map[*resource.Resource]bool{}
+	for _, s := range selectors {
+		rs, err := m.Select(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			result[r] = true
+		}
+	}
+	return result, nil
+}
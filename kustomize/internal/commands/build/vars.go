@@ -0,0 +1,77 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/ifc"
+	"sigs.k8s.io/kustomize/v3/api/kv"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+const (
+	flagVarName     = "var"
+	flagVarFileName = "var-file"
+)
+
+var (
+	flagVarValue     []string
+	flagVarFileValue []string
+)
+
+func addFlagsVars(set *pflag.FlagSet) {
+	set.StringArrayVar(
+		&flagVarValue, flagVarName, nil,
+		"Define a var, e.g. GIT_SHA=0a1b2c, or override a var declared "+
+			"in a kustomization.  May be repeated.")
+	set.StringArrayVar(
+		&flagVarFileValue, flagVarFileName, nil,
+		"Define vars from a file of NAME=value lines, as used by "+
+			"envs in configMapGenerator.  May be repeated; "+
+			"--"+flagVarName+" takes precedence.")
+}
+
+// readFlagsVars returns the values of the vars given by
+// the flags.  Var files are read relative to the current
+// directory, not to the kustomization.
+func readFlagsVars(
+	fSys filesys.FileSystem, v ifc.Validator) (map[string]string, error) {
+	if len(flagVarValue) == 0 && len(flagVarFileValue) == 0 {
+		return nil, nil
+	}
+	files, err := varFilePaths()
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := kv.NewLoader(loader.NewFileLoaderAtRoot(fSys), v).Load(
+		types.KvPairSources{
+			EnvSources:     files,
+			LiteralSources: flagVarValue,
+		})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		result[p.Key] = p.Value
+	}
+	return result, nil
+}
+
+// varFilePaths returns the absolute paths of the var files.
+func varFilePaths() ([]string, error) {
+	var result []string
+	for _, f := range flagVarFileValue {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, abs)
+	}
+	return result, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	resdiff "sigs.k8s.io/kustomize/v3/api/diff"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/ifc"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+)
+
+const (
+	flagWatchName     = "watch"
+	flagWatchDiffName = "watch-diff"
+)
+
+// How often the inputs of a build are polled for
+// changes, and how long they must then stay unchanged
+// before building again, so that a burst of changes,
+// e.g. an editor saving several files, causes one build.
+var (
+	watchInterval = 500 * time.Millisecond
+	watchDebounce = 300 * time.Millisecond
+)
+
+func (o *Options) validateFlagsWatch() error {
+	if o.watchDiff && !o.watch {
+		return fmt.Errorf(
+			"--%s requires --%s", flagWatchDiffName, flagWatchName)
+	}
+	if !o.watch {
+		return nil
+	}
+	if o.allVariants {
+		return fmt.Errorf(
+			"--%s cannot be used with --%s", flagWatchName, flagAllVariantsName)
+	}
+	if o.explain != noExplain {
+		return fmt.Errorf(
+			"--%s cannot be used with --%s", flagExplainName, flagWatchName)
+	}
+	return nil
+}
+
+// runWatch builds, then builds again whenever a file or
+// directory that the previous build read changes, until
+// the stop channel, which may be nil, is closed.  Build
+// errors are logged, and don't stop the watching.
+//
+// The loader at the kustomization is kept from build to
+// build, so remote bases and files, which aren't watched,
+// are fetched once, by the build that first needs them.
+func (o *Options) runWatch(
+	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader, stop <-chan struct{}) error {
+	ldr, err := o.newLoader(fSys)
+	if err != nil {
+		return err
+	}
+	defer ldr.Cleanup()
+	if cloneSpec, _, _ := ldr.Repo(); cloneSpec != "" {
+		return fmt.Errorf(
+			"--%s needs a local kustomization; '%s' is remote",
+			flagWatchName, o.kustomizationPath)
+	}
+	var previous resmap.ResMap
+	for {
+		r := loader.NewRecorder()
+		m, err := o.buildWatched(out, v, fSys, rf, ptf, pl, ldr, r, previous)
+		if err != nil {
+			log.Printf("build failed: %v\n", err)
+		} else {
+			previous = m
+		}
+		paths := r.Paths()
+		files, err := varFilePaths()
+		if err != nil {
+			return err
+		}
+		paths = append(paths, files...)
+		changed, ok := waitForChange(fSys, paths, stop)
+		if !ok {
+			return nil
+		}
+		log.Printf("'%s' changed; building again\n", changed)
+	}
+}
+
+// buildWatched does one build of a watch, recording the
+// files and directories it reads, and emits the output,
+// or its differences from the previous output.
+func (o *Options) buildWatched(
+	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader, ldr ifc.Loader, r *loader.Recorder,
+	previous resmap.ResMap) (resmap.ResMap, error) {
+	rldr, err := loader.WithRecorder(ldr, r)
+	if err != nil {
+		return nil, err
+	}
+	kt, err := o.newTarget(rldr, v, fSys, rf, ptf, pl)
+	if err != nil {
+		return nil, err
+	}
+	p := o.profile.start(kt)
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		return nil, err
+	}
+	err = filterResources(m, o.selects, o.excludes)
+	if err != nil {
+		return nil, err
+	}
+	if o.watchDiff && previous != nil {
+		_, err = out.Write(resdiff.ResMaps(previous, m, nil).Report())
+	} else {
+		wo := *o
+		if kt.SortsOutput() && !o.outOrderSet {
+			// Respect the order specified in the kustomization.
+			wo.outOrder = none
+		}
+		err = wo.emitResources(out, fSys, m)
+	}
+	if err != nil {
+		return m, err
+	}
+	return m, o.profile.emit(o.profileOut, p)
+}
+
+// waitForChange polls the given paths until one of them
+// changes, then until none has changed for the debounce
+// period, and returns the first path that changed.  It
+// returns false if the stop channel is closed first.
+func waitForChange(
+	fSys filesys.FileSystem, paths []string,
+	stop <-chan struct{}) (string, bool) {
+	before := snapshot(fSys, paths)
+	for {
+		select {
+		case <-stop:
+			return "", false
+		case <-time.After(watchInterval):
+		}
+		now := snapshot(fSys, paths)
+		changed := firstChange(paths, before, now)
+		if changed == "" {
+			continue
+		}
+		for {
+			select {
+			case <-stop:
+				return "", false
+			case <-time.After(watchDebounce):
+			}
+			later := snapshot(fSys, paths)
+			if firstChange(paths, now, later) == "" {
+				return changed, true
+			}
+			now = later
+		}
+	}
+}
+
+// snapshot returns, for each path that exists, a summary
+// of its state: the entries of a directory, or the sha256
+// of the content of a file.
+func snapshot(fSys filesys.FileSystem, paths []string) map[string]string {
+	result := make(map[string]string, len(paths))
+	for _, p := range paths {
+		if fSys.IsDir(p) {
+			entries, err := fSys.Glob(filepath.Join(p, "*"))
+			if err == nil {
+				sort.Strings(entries)
+				result[p] = "dir:" + strings.Join(entries, "\n")
+			}
+			continue
+		}
+		content, err := fSys.ReadFile(p)
+		if err == nil {
+			result[p] = fmt.Sprintf("file:%x", sha256.Sum256(content))
+		}
+	}
+	return result
+}
+
+// firstChange returns the first of the paths whose state
+// differs between the snapshots, or "" if none does.
+func firstChange(paths []string, before, after map[string]string) string {
+	for _, p := range paths {
+		if before[p] != after[p] {
+			return p
+		}
+	}
+	return ""
+}
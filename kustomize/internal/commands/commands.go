@@ -12,7 +12,9 @@ import (
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/build"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/config"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/create"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/diff"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/vendoring"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/version"
 	"sigs.k8s.io/kustomize/v3/api/filesys"
 	"sigs.k8s.io/kustomize/v3/api/resmap"
@@ -45,9 +47,13 @@ See https://sigs.k8s.io/kustomize
 		build.NewCmdBuild(
 			stdOut, fSys, v,
 			rf, pf),
-		edit.NewCmdEdit(fSys, v, uf),
+		diff.NewCmdDiff(
+			stdOut, fSys, v,
+			rf, pf),
+		edit.NewCmdEdit(fSys, v, uf, rf, pf),
 		create.NewCmdCreate(fSys, uf),
 		config.NewCmdConfig(fSys),
+		vendoring.NewCmdVendor(stdOut, fSys),
 		version.NewCmdVersion(stdOut),
 	)
 	c.PersistentFlags().AddGoFlagSet(flag.CommandLine)
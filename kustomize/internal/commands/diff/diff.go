@@ -0,0 +1,208 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	resdiff "sigs.k8s.io/kustomize/v3/api/diff"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/ifc"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/pgmconfig"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+	"sigs.k8s.io/kustomize/v3/pkg/target"
+)
+
+// Options contain the options for running a diff
+type Options struct {
+	leftPath       string
+	rightPath      string
+	ignoredFields  []string
+	loadRestrictor loader.LoadRestrictorFunc
+	offline        bool
+	mirrors        map[string]string
+}
+
+var examples = `
+To compare the output of two overlays, run
+
+  kustomize diff overlays/staging overlays/production
+
+To compare a build to resources exported from a cluster, run
+
+  kubectl get deployments,services -o yaml > live.yaml
+  kustomize diff overlays/production live.yaml
+
+Each argument can be a directory containing a kustomization
+file, a URL as accepted by 'kustomize build', a directory of
+plain YAML or JSON resources, or a single such file.
+`
+
+// NewCmdDiff creates a new diff command.
+func NewCmdDiff(
+	out io.Writer, fSys filesys.FileSystem,
+	v ifc.Validator, rf *resmap.Factory,
+	ptf resmap.PatchFactory) *cobra.Command {
+	var o Options
+
+	pluginConfig := plugins.DefaultPluginConfig()
+	pl := plugins.NewLoader(pluginConfig, rf)
+
+	cmd := &cobra.Command{
+		Use:          "diff {left} {right}",
+		Short:        "Print the field level differences between two sets of resources",
+		Example:      examples,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunDiff(out, v, fSys, rf, ptf, pl)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(
+		&o.ignoredFields,
+		"ignore", resdiff.DefaultIgnoredFields,
+		"Comma separated list of field paths, e.g. 'metadata/uid', "+
+			"to ignore when comparing resources. "+
+			"The default ignores fields populated by the server.")
+	cmd.Flags().BoolVar(
+		&o.offline,
+		"offline", false,
+		"If true, take remote bases only from the local cache of git clones.")
+	loader.AddFlagLoadRestrictor(cmd.Flags())
+	loader.AddFlagGitMirrors(cmd.Flags())
+	plugins.AddFlagEnablePlugins(
+		cmd.Flags(), &pluginConfig.Enabled)
+	return cmd
+}
+
+// Validate validates diff command.
+func (o *Options) Validate(args []string) (err error) {
+	if len(args) != 2 {
+		return errors.New("specify two paths to compare")
+	}
+	o.leftPath = args[0]
+	o.rightPath = args[1]
+	o.loadRestrictor, err = loader.ValidateFlagLoadRestrictor()
+	if err != nil {
+		return err
+	}
+	o.mirrors, err = loader.ValidateFlagGitMirrors()
+	return
+}
+
+// RunDiff runs diff command.
+func (o *Options) RunDiff(
+	out io.Writer, v ifc.Validator, fSys filesys.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader) error {
+	left, err := o.load(o.leftPath, v, fSys, rf, ptf, pl)
+	if err != nil {
+		return err
+	}
+	right, err := o.load(o.rightPath, v, fSys, rf, ptf, pl)
+	if err != nil {
+		return err
+	}
+	d := resdiff.ResMaps(left, right, o.ignoredFields)
+	_, err = out.Write(d.Report())
+	return err
+}
+
+// load returns the resources at the given path, building
+// them if the path holds a kustomization.
+func (o *Options) load(
+	path string, v ifc.Validator, fSys filesys.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader) (resmap.ResMap, error) {
+	if fSys.Exists(path) && !fSys.IsDir(path) {
+		return loadFile(fSys, rf, path)
+	}
+	pins, err := loader.ReadLock(fSys, path)
+	if err != nil {
+		return nil, err
+	}
+	ldr, err := loader.NewCachingLoader(
+		o.loadRestrictor, path, fSys,
+		loader.RemoteOptions{
+			Offline: o.offline,
+			Locker:  loader.NewLocker(pins, false),
+			Mirrors: o.mirrors,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer ldr.Cleanup()
+	if !hasKustomization(ldr) {
+		return loadDirectory(fSys, rf, ldr.Root())
+	}
+	kt, err := target.NewKustTarget(ldr, v, rf, ptf, pl)
+	if err != nil {
+		return nil, err
+	}
+	return kt.MakeCustomizedResMap()
+}
+
+func hasKustomization(ldr ifc.Loader) bool {
+	for _, kf := range pgmconfig.RecognizedKustomizationFileNames() {
+		if _, err := ldr.Load(kf); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func loadFile(
+	fSys filesys.FileSystem, rf *resmap.Factory,
+	path string) (resmap.ResMap, error) {
+	content, err := fSys.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := rf.NewResMapFromBytes(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading resources from '%s'", path)
+	}
+	return m, nil
+}
+
+// loadDirectory returns the resources in all the
+// YAML and JSON files in and below the directory.
+func loadDirectory(
+	fSys filesys.FileSystem, rf *resmap.Factory,
+	dir string) (resmap.ResMap, error) {
+	result := resmap.New()
+	err := fSys.Walk(dir, func(
+		path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		m, err := loadFile(fSys, rf, path)
+		if err != nil {
+			return err
+		}
+		return result.AppendAll(m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
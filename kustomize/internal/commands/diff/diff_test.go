@@ -0,0 +1,91 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/testutils/valtest"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/transformer"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+)
+
+func TestDiffValidate(t *testing.T) {
+	var o Options
+	if err := o.Validate([]string{"a"}); err == nil {
+		t.Fatalf("expected error for one path")
+	}
+	if err := o.Validate([]string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.leftPath != "a" || o.rightPath != "b" {
+		t.Fatalf("unexpected paths %s %s", o.leftPath, o.rightPath)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+namePrefix: p-
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("/app/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`))
+	fSys.Mkdir("/live")
+	fSys.WriteFile("/live/services.yaml", []byte(`
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: p-web
+    uid: "1234"
+  spec:
+    ports:
+    - port: 8080
+  status:
+    loadBalancer: {}
+`))
+	fSys.WriteFile("/live/configmap.json", []byte(`
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}}
+`))
+	fSys.WriteFile("/live/README.md", []byte(`not a resource`))
+
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	o := Options{}
+	if err := o.Validate([]string{"/app", "/live"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	o.ignoredFields = []string{"status", "metadata/uid"}
+	var out bytes.Buffer
+	err := o.RunDiff(
+		&out, valtest_test.MakeFakeValidator(), fSys, rf,
+		transformer.NewFactoryImpl(),
+		plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `only in right: ~G_v1_ConfigMap|~X|cm
+changed ~G_v1_Service|~X|p-web
+  spec/ports[0]/port: 80 -> 8080
+`
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out.String())
+	}
+}
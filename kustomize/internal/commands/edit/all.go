@@ -7,17 +7,20 @@ import (
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/add"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/fix"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/lock"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/remove"
 	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/edit/set"
 	"sigs.k8s.io/kustomize/v3/api/filesys"
 	"sigs.k8s.io/kustomize/v3/api/ifc"
 	"sigs.k8s.io/kustomize/v3/api/kv"
 	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
 )
 
 // NewCmdEdit returns an instance of 'edit' subcommand.
 func NewCmdEdit(
-	fSys filesys.FileSystem, v ifc.Validator, kf ifc.KunstructuredFactory) *cobra.Command {
+	fSys filesys.FileSystem, v ifc.Validator, kf ifc.KunstructuredFactory,
+	rf *resmap.Factory, ptf resmap.PatchFactory) *cobra.Command {
 	c := &cobra.Command{
 		Use:   "edit",
 		Short: "Edits a kustomization file",
@@ -31,6 +34,9 @@ func NewCmdEdit(
 
 	# Sets the namesuffix field
 	kustomize edit set namesuffix <suffix-value>
+
+	# Pins remote bases to their current commits
+	kustomize edit lock
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -42,6 +48,7 @@ func NewCmdEdit(
 			kf),
 		set.NewCmdSet(fSys, v),
 		fix.NewCmdFix(fSys),
+		lock.NewCmdLock(fSys, v, rf, ptf),
 		remove.NewCmdRemove(fSys, v),
 	)
 	return c
@@ -0,0 +1,71 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package lock
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/ifc"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+	"sigs.k8s.io/kustomize/v3/pkg/target"
+)
+
+// NewCmdLock returns an instance of 'lock' subcommand.
+func NewCmdLock(
+	fSys filesys.FileSystem, v ifc.Validator,
+	rf *resmap.Factory, ptf resmap.PatchFactory) *cobra.Command {
+	pluginConfig := plugins.DefaultPluginConfig()
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pin the remote bases of the kustomization to their current commits",
+		Long: "Writes " + loader.LockFileName + " beside the kustomization file, " +
+			"pinning each remote git base reached by the build to the commit its " +
+			"ref currently resolves to.  Later builds check out the pinned commits.",
+		Example: `
+	# Create or refresh the lock file
+	kustomize edit lock
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mirrors, err := loader.ValidateFlagGitMirrors()
+			if err != nil {
+				return err
+			}
+			return RunLock(
+				fSys, v, rf, ptf, plugins.NewLoader(pluginConfig, rf), mirrors)
+		},
+	}
+	loader.AddFlagGitMirrors(cmd.Flags())
+	plugins.AddFlagEnablePlugins(
+		cmd.Flags(), &pluginConfig.Enabled)
+	return cmd
+}
+
+// RunLock runs `lock` command.  Existing pins are ignored,
+// so that every ref is resolved anew.  Remote bases are
+// fetched from the given mirrors, if any, but locked
+// under their own names.
+func RunLock(
+	fSys filesys.FileSystem, v ifc.Validator,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader, mirrors map[string]string) error {
+	locker := loader.NewLocker(nil, false)
+	ldr, err := loader.NewCachingLoader(
+		loader.RestrictionRootOnly, loader.CWD, fSys,
+		loader.RemoteOptions{Locker: locker, Mirrors: mirrors})
+	if err != nil {
+		return err
+	}
+	defer ldr.Cleanup()
+	kt, err := target.NewKustTarget(ldr, v, rf, ptf, pl)
+	if err != nil {
+		return err
+	}
+	_, err = kt.AccumulateTarget()
+	if err != nil {
+		return err
+	}
+	return loader.WriteLock(fSys, loader.CWD, locker.Lock())
+}
@@ -100,7 +100,7 @@ func (o *setImageOptions) Validate(args []string) error {
 
 	for _, arg := range args {
 
-		img, err := parse(arg)
+		img, err := ParseImage(arg)
 		if err != nil {
 			return err
 		}
@@ -142,7 +142,10 @@ func (o *setImageOptions) RunSetImage(fSys filesys.FileSystem) error {
 	return mf.Write(m)
 }
 
-func parse(arg string) (types.Image, error) {
+// ParseImage parses an image override given in one of the
+// forms <image>=<newimage>:<newtag>, <image>=<newimage>@<digest>,
+// <image>=<newimage>, <image>:<newtag> or <image>@<digest>.
+func ParseImage(arg string) (types.Image, error) {
 
 	// matches if there is an image name to overwrite
 	// <image>=<new-image><:|@><new-tag>
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
@@ -39,6 +40,7 @@ func determineFieldOrder() []string {
 
 	ordered := []string{
 		"Resources",
+		"Components",
 		"Bases",
 		"NamePrefix",
 		"NameSuffix",
@@ -46,6 +48,7 @@ func determineFieldOrder() []string {
 		"Crds",
 		"CommonLabels",
 		"CommonAnnotations",
+		"Exclude",
 		"PatchesStrategicMerge",
 		"PatchesJson6902",
 		"Patches",
@@ -53,12 +56,17 @@ func determineFieldOrder() []string {
 		"SecretGenerator",
 		"GeneratorOptions",
 		"Vars",
+		"Replacements",
 		"Images",
 		"Replicas",
 		"Configurations",
 		"Generators",
 		"Transformers",
 		"Inventory",
+		"SortOptions",
+		"Variants",
+		"BuildMetadata",
+		"AllowDirs",
 	}
 
 	// Add deprecated fields here.
@@ -108,18 +116,26 @@ type kustomizationFile struct {
 
 // NewKustomizationFile returns a new instance.
 func NewKustomizationFile(fSys filesys.FileSystem) (*kustomizationFile, error) { // nolint
+	return NewKustomizationFileInDir(fSys, "")
+}
+
+// NewKustomizationFileInDir returns a new instance
+// for the kustomization file in the given directory.
+func NewKustomizationFileInDir(
+	fSys filesys.FileSystem, dir string) (*kustomizationFile, error) { // nolint
 	mf := &kustomizationFile{fSys: fSys}
-	err := mf.validate()
+	err := mf.validate(dir)
 	if err != nil {
 		return nil, err
 	}
 	return mf, nil
 }
 
-func (mf *kustomizationFile) validate() error {
+func (mf *kustomizationFile) validate(dir string) error {
 	match := 0
 	var path []string
 	for _, kfilename := range pgmconfig.RecognizedKustomizationFileNames() {
+		kfilename = filepath.Join(dir, kfilename)
 		if mf.fSys.Exists(kfilename) {
 			match += 1
 			path = append(path, kfilename)
@@ -20,6 +20,7 @@ func TestFieldOrder(t *testing.T) {
 		"APIVersion",
 		"Kind",
 		"Resources",
+		"Components",
 		"Bases",
 		"NamePrefix",
 		"NameSuffix",
@@ -27,6 +28,7 @@ func TestFieldOrder(t *testing.T) {
 		"Crds",
 		"CommonLabels",
 		"CommonAnnotations",
+		"Exclude",
 		"PatchesStrategicMerge",
 		"PatchesJson6902",
 		"Patches",
@@ -34,12 +36,17 @@ func TestFieldOrder(t *testing.T) {
 		"SecretGenerator",
 		"GeneratorOptions",
 		"Vars",
+		"Replacements",
 		"Images",
 		"Replicas",
 		"Configurations",
 		"Generators",
 		"Transformers",
 		"Inventory",
+		"SortOptions",
+		"Variants",
+		"BuildMetadata",
+		"AllowDirs",
 	}
 	actual := determineFieldOrder()
 	if len(expected) != len(actual) {
@@ -0,0 +1,496 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package vendoring implements the 'vendor' command, which
+// copies the remote bases of a kustomization into its tree.
+package vendoring
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kustomize/v3/internal/commands/kustfile"
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// VendorDir is the directory, beside the top
+	// kustomization file, holding vendored bases.
+	VendorDir = "vendor"
+
+	// ManifestFileName is the file in VendorDir recording
+	// where each vendored base came from.
+	ManifestFileName = "manifest.yaml"
+)
+
+// Options contain the options for running vendor.
+type Options struct {
+	dir     string
+	offline bool
+	mirrors map[string]string
+}
+
+var examples = `
+To copy the remote bases of the kustomization in the
+current directory into ./vendor, run
+
+  kustomize vendor
+
+The resources entries naming remote bases, and the
+resources and patches entries naming remote files, are
+rewritten to the vendored copies, so the kustomization
+then builds without git or the network.  Where each copy
+came from is recorded in vendor/` + ManifestFileName + `.
+`
+
+// NewCmdVendor creates a new vendor command.
+func NewCmdVendor(out io.Writer, fSys filesys.FileSystem) *cobra.Command {
+	var o Options
+	cmd := &cobra.Command{
+		Use:          "vendor [dir]",
+		Short:        "Copy the remote bases of a kustomization into its vendor directory",
+		Example:      examples,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunVendor(out, fSys)
+		},
+	}
+	cmd.Flags().BoolVar(
+		&o.offline,
+		"offline", false,
+		"If true, take remote bases and files only from the local cache.")
+	loader.AddFlagGitMirrors(cmd.Flags())
+	return cmd
+}
+
+// Validate validates vendor command.
+func (o *Options) Validate(args []string) (err error) {
+	switch len(args) {
+	case 0:
+		o.dir = loader.CWD
+	case 1:
+		o.dir = args[0]
+	default:
+		return errors.New("specify one path to a kustomization directory")
+	}
+	o.mirrors, err = loader.ValidateFlagGitMirrors()
+	return
+}
+
+// RunVendor runs vendor command.  Remote bases are checked out
+// at the commits pinned by the lock file, if there is one.
+func (o *Options) RunVendor(out io.Writer, fSys filesys.FileSystem) error {
+	lock, err := loader.ReadLock(fSys, o.dir)
+	if err != nil {
+		return err
+	}
+	opts := loader.RemoteOptions{
+		Offline: o.offline,
+		Locker:  loader.NewLocker(lock, false),
+		Mirrors: o.mirrors,
+	}
+	v, err := newVendorer(fSys, o.dir,
+		loader.NewRemoteCloner(opts).Clone,
+		loader.NewRemoteFetcher(opts).Fetch)
+	if err != nil {
+		return err
+	}
+	err = v.run()
+	if err != nil {
+		return err
+	}
+	for _, b := range v.manifest.Bases {
+		if v.copied[b.Dir] {
+			_, err = io.WriteString(out, "vendored "+b.Repo+" into "+
+				filepath.Join(VendorDir, b.Dir)+"\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range v.manifest.Files {
+		if v.copied[f.Path] {
+			_, err = io.WriteString(out, "vendored "+f.Url+" into "+
+				filepath.Join(VendorDir, f.Path)+"\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Manifest lists the vendored bases and files.
+type Manifest struct {
+	Bases []VendoredBase `json:"bases,omitempty"`
+	Files []VendoredFile `json:"files,omitempty"`
+}
+
+// VendoredBase records where a vendored base came from.
+type VendoredBase struct {
+	// Dir is the copy of the repository, relative to VendorDir.
+	Dir string `json:"dir"`
+	// Repo is the clone spec of the repository.
+	Repo string `json:"repo"`
+	// Ref is the ref given in the url, if any.
+	Ref string `json:"ref,omitempty"`
+	// Commit is the commit that was copied.
+	Commit string `json:"commit"`
+}
+
+// VendoredFile records where a vendored remote file came from.
+type VendoredFile struct {
+	// Path is the copy of the file, relative to VendorDir.
+	Path string `json:"path"`
+	// Url is the url the file was fetched from.
+	Url string `json:"url"`
+	// Sha256 is the sha256 of the file.
+	Sha256 string `json:"sha256"`
+}
+
+// vendorer walks a kustomization tree, vendoring remote bases.
+type vendorer struct {
+	fSys  filesys.FileSystem
+	root  filesys.ConfirmedDir
+	clone func(url string) (*loader.RemoteBase, error)
+	fetch func(url string) ([]byte, error)
+	// Kustomization directories already visited.
+	visited map[string]bool
+	// Vendored directories and files written by this run.
+	copied   map[string]bool
+	manifest Manifest
+}
+
+func newVendorer(
+	fSys filesys.FileSystem, dir string,
+	clone func(string) (*loader.RemoteBase, error),
+	fetch func(string) ([]byte, error)) (*vendorer, error) {
+	root, f, err := fSys.CleanedAbs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if f != "" {
+		return nil, errors.Errorf("'%s' must be a directory", dir)
+	}
+	return &vendorer{
+		fSys:    fSys,
+		root:    root,
+		clone:   clone,
+		fetch:   fetch,
+		visited: make(map[string]bool),
+		copied:  make(map[string]bool),
+	}, nil
+}
+
+func (v *vendorer) run() error {
+	err := v.readManifest()
+	if err != nil {
+		return err
+	}
+	err = v.visit(v.root.String())
+	if err != nil {
+		return err
+	}
+	return v.writeManifest()
+}
+
+// visit vendors the remote bases, components and files of
+// the kustomization in the given directory, rewriting its
+// resources, components and patches fields, then visits
+// the kustomizations they refer to.
+func (v *vendorer) visit(dir string) error {
+	if v.visited[dir] {
+		return nil
+	}
+	v.visited[dir] = true
+	mf, err := kustfile.NewKustomizationFileInDir(v.fSys, dir)
+	if err != nil {
+		return err
+	}
+	k, err := mf.Read()
+	if err != nil {
+		return err
+	}
+	var next []string
+	changed := false
+	for _, entries := range [][]string{k.Resources, k.Components} {
+		for i, entry := range entries {
+			if loader.IsRemoteFile(entry) {
+				entries[i], err = v.vendorFileEntry(dir, entry)
+				if err != nil {
+					return err
+				}
+				changed = true
+				continue
+			}
+			if !loader.IsRemoteBase(entry) {
+				path := filepath.Join(dir, entry)
+				if v.isKustomizationDir(path) {
+					next = append(next, path)
+				}
+				continue
+			}
+			path, err := v.vendor(entry)
+			if err != nil {
+				return errors.Wrapf(err, "vendoring '%s'", entry)
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			entries[i] = filepath.ToSlash(rel)
+			changed = true
+			if v.isKustomizationDir(path) {
+				next = append(next, path)
+			}
+		}
+	}
+	for i := range k.Patches {
+		if loader.IsRemoteFile(k.Patches[i].Path) {
+			k.Patches[i].Path, err = v.vendorFileEntry(dir, k.Patches[i].Path)
+			if err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+	if changed {
+		err = mf.Write(k)
+		if err != nil {
+			return err
+		}
+	}
+	for _, d := range next {
+		err = v.visit(d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *vendorer) isKustomizationDir(path string) bool {
+	if !v.fSys.IsDir(path) {
+		return false
+	}
+	_, err := kustfile.NewKustomizationFileInDir(v.fSys, path)
+	return err == nil
+}
+
+// vendor copies the repository holding the remote
+// base into the vendor directory, if not already
+// copied by this run, and returns the absolute
+// path of the base in the copy.
+func (v *vendorer) vendor(url string) (string, error) {
+	rb, err := v.clone(url)
+	if err != nil {
+		return "", err
+	}
+	dir := vendoredDir(rb)
+	target := filepath.Join(v.root.String(), VendorDir, dir)
+	if !v.copied[dir] {
+		err = v.fSys.RemoveAll(target)
+		if err != nil {
+			return "", err
+		}
+		err = v.copyClone(rb.CloneDir, target)
+		if err != nil {
+			return "", err
+		}
+		v.copied[dir] = true
+		v.record(VendoredBase{
+			Dir:    dir,
+			Repo:   rb.Repo,
+			Ref:    rb.Ref,
+			Commit: rb.Commit,
+		})
+	}
+	return filepath.Join(target, rb.Path), nil
+}
+
+// vendoredDir returns the directory, relative to VendorDir,
+// holding the copy of the remote base's repository, e.g.
+// github.com/someOrg/someRepo@v1.0.0.  A slash in the ref,
+// as in release/1.2, is escaped, so that the copy is one
+// directory, github.com/someOrg/someRepo@release%2F1.2.
+func vendoredDir(rb *loader.RemoteBase) string {
+	if rb.Ref == "" {
+		return rb.Name
+	}
+	return rb.Name + "@" + url.PathEscape(rb.Ref)
+}
+
+// vendorFileEntry vendors the remote file named by an
+// entry of the kustomization in the given directory,
+// returning the entry to replace it with.
+func (v *vendorer) vendorFileEntry(dir, entry string) (string, error) {
+	path, err := v.vendorFile(entry)
+	if err != nil {
+		return "", errors.Wrapf(err, "vendoring '%s'", entry)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// vendorFile fetches the remote file into the vendor
+// directory, if not already fetched by this run, and
+// returns the absolute path of the copy.
+func (v *vendorer) vendorFile(location string) (string, error) {
+	p, err := vendoredFile(location)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(v.root.String(), VendorDir, p)
+	if v.copied[p] {
+		return target, nil
+	}
+	content, err := v.fetch(location)
+	if err != nil {
+		return "", err
+	}
+	err = v.fSys.MkdirAll(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	err = v.fSys.WriteFile(target, content)
+	if err != nil {
+		return "", err
+	}
+	v.copied[p] = true
+	v.recordFile(VendoredFile{
+		Path:   p,
+		Url:    location,
+		Sha256: fmt.Sprintf("%x", sha256.Sum256(content)),
+	})
+	return target, nil
+}
+
+// vendoredFile returns the path, relative to VendorDir,
+// of the copy of the remote file at the location, i.e.
+// the host and path of its url, with a hash of the url,
+// without any pin, added to the file name, so that urls
+// differing only in their query don't share a copy, e.g.
+// example.com/releases/v1/release-0123abcd.yaml.
+func vendoredFile(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	p := path.Clean("/" + u.Path)
+	if u.Host == "" || p == "/" || strings.HasSuffix(u.Path, "/") {
+		return "", errors.Errorf("no file name in '%s'", location)
+	}
+	u.Fragment = ""
+	sum := sha256.Sum256([]byte(u.String()))
+	ext := path.Ext(p)
+	return filepath.FromSlash(fmt.Sprintf("%s%s-%x%s",
+		u.Host, strings.TrimSuffix(p, ext), sum[:4], ext)), nil
+}
+
+// copyClone copies the clone, without its git metadata.
+func (v *vendorer) copyClone(from, to string) error {
+	return v.fSys.Walk(from, func(
+		path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		if filepath.Base(path) == ".git" {
+			// A submodule's .git is a file.
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return v.fSys.MkdirAll(filepath.Join(to, rel))
+		}
+		content, err := v.fSys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return v.fSys.WriteFile(filepath.Join(to, rel), content)
+	})
+}
+
+// record adds the base to the manifest, replacing
+// any earlier entry for the same directory.
+func (v *vendorer) record(b VendoredBase) {
+	for i := range v.manifest.Bases {
+		if v.manifest.Bases[i].Dir == b.Dir {
+			v.manifest.Bases[i] = b
+			return
+		}
+	}
+	v.manifest.Bases = append(v.manifest.Bases, b)
+}
+
+// recordFile adds the file to the manifest, replacing
+// any earlier entry for the same path.
+func (v *vendorer) recordFile(f VendoredFile) {
+	for i := range v.manifest.Files {
+		if v.manifest.Files[i].Path == f.Path {
+			v.manifest.Files[i] = f
+			return
+		}
+	}
+	v.manifest.Files = append(v.manifest.Files, f)
+}
+
+func (v *vendorer) manifestPath() string {
+	return filepath.Join(v.root.String(), VendorDir, ManifestFileName)
+}
+
+// readManifest reads the manifest of an earlier run, so
+// that bases vendored then, whose entries no longer
+// name remote bases, remain listed.
+func (v *vendorer) readManifest() error {
+	if !v.fSys.Exists(v.manifestPath()) {
+		return nil
+	}
+	content, err := v.fSys.ReadFile(v.manifestPath())
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(
+		yaml.Unmarshal(content, &v.manifest),
+		"reading %s", v.manifestPath())
+}
+
+func (v *vendorer) writeManifest() error {
+	if len(v.manifest.Bases) == 0 && len(v.manifest.Files) == 0 {
+		return nil
+	}
+	sort.Slice(v.manifest.Bases, func(i, j int) bool {
+		return v.manifest.Bases[i].Dir < v.manifest.Bases[j].Dir
+	})
+	sort.Slice(v.manifest.Files, func(i, j int) bool {
+		return v.manifest.Files[i].Path < v.manifest.Files[j].Path
+	})
+	content, err := yaml.Marshal(v.manifest)
+	if err != nil {
+		return err
+	}
+	return v.fSys.WriteFile(v.manifestPath(), content)
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package vendoring
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/filesys"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+)
+
+func TestVendoredDir(t *testing.T) {
+	rb := &loader.RemoteBase{Name: "github.com/someOrg/someRepo"}
+	if actual := vendoredDir(rb); actual != "github.com/someOrg/someRepo" {
+		t.Errorf("unexpected dir %s", actual)
+	}
+	rb.Ref = "v1.0.0"
+	if actual := vendoredDir(rb); actual != "github.com/someOrg/someRepo@v1.0.0" {
+		t.Errorf("unexpected dir %s", actual)
+	}
+	rb.Ref = "release/1.2"
+	if actual := vendoredDir(rb); actual != "github.com/someOrg/someRepo@release%2F1.2" {
+		t.Errorf("unexpected dir %s", actual)
+	}
+}
+
+func TestVendoredFile(t *testing.T) {
+	for location, expected := range map[string]string{
+		"https://example.com/a/release.yaml":             "example.com/a/release-a42956ec.yaml",
+		"https://example.com/a/../release.yaml?x=1#y":    "example.com/release-9e8b5b3a.yaml",
+		"https://example.com/a/../release.yaml?x=2":      "example.com/release-63bf1f49.yaml",
+		"https://example.com/release.yaml#sha256=9f86d0": "example.com/release-8fb7a650.yaml",
+	} {
+		actual, err := vendoredFile(location)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if actual != expected {
+			t.Errorf("expected %s for %s, got %s", expected, location, actual)
+		}
+	}
+	if _, err := vendoredFile("https://example.com/"); err == nil {
+		t.Errorf("expected an error for a url without a file name")
+	}
+}
+
+func TestVendor(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	fSys.Mkdir("/clone")
+	fSys.Mkdir("/clone/.git")
+	fSys.Mkdir("/clone/base")
+	fSys.WriteFile("/clone/.git/HEAD", []byte("ref: refs/heads/master\n"))
+	fSys.WriteFile("/clone/base/module/.git",
+		[]byte("gitdir: ../../.git/modules/module\n"))
+	fSys.WriteFile("/clone/base/kustomization.yaml", []byte(`
+resources:
+- service.yaml
+`))
+	fSys.WriteFile("/clone/base/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	fSys.WriteFile("/app/overlay/kustomization.yaml", []byte(`
+# The production overlay.
+resources:
+- https://github.com/someOrg/someRepo//base?ref=v1.0.0
+- ../common
+- https://example.com/crds.yaml
+patches:
+- path: https://example.com/patches/replicas.yaml
+`))
+	fSys.WriteFile("/app/common/kustomization.yaml", []byte(`
+components:
+- github.com/someOrg/someRepo//base?ref=v1.0.0
+`))
+	clones := 0
+	clone := func(url string) (*loader.RemoteBase, error) {
+		clones++
+		return &loader.RemoteBase{
+			Repo:     "https://github.com/someOrg/someRepo.git",
+			Name:     "github.com/someOrg/someRepo",
+			Ref:      "v1.0.0",
+			Commit:   "0a013f3603b1ab3924b90ac5e38df7cc1cf4c187",
+			CloneDir: "/clone",
+			Path:     "base",
+		}, nil
+	}
+	fetches := 0
+	fetch := func(url string) ([]byte, error) {
+		fetches++
+		return []byte("kind: " + url + "\n"), nil
+	}
+	v, err := newVendorer(fSys, "/app/overlay", clone, fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = v.run()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if clones != 2 || fetches != 2 {
+		t.Fatalf("expected 2 clones and 2 fetches, got %d and %d",
+			clones, fetches)
+	}
+	vendored := "/app/overlay/vendor/github.com/someOrg/someRepo@v1.0.0"
+	if !fSys.Exists(vendored + "/base/service.yaml") {
+		t.Fatalf("expected base to be vendored")
+	}
+	if fSys.Exists(vendored + "/.git") {
+		t.Fatalf("expected git metadata not to be vendored")
+	}
+	if fSys.Exists(vendored + "/base/module/.git") {
+		t.Fatalf("expected git metadata of submodules not to be vendored")
+	}
+	expectContains(t, fSys, "/app/overlay/kustomization.yaml",
+		"# The production overlay.",
+		"- vendor/github.com/someOrg/someRepo@v1.0.0/base\n",
+		"- ../common\n",
+		"- vendor/example.com/crds-21346e27.yaml\n",
+		"- path: vendor/example.com/patches/replicas-cb93b9ba.yaml\n")
+	expectContains(t, fSys, "/app/overlay/vendor/example.com/crds-21346e27.yaml",
+		"kind: https://example.com/crds.yaml\n")
+	expectContains(t, fSys, "/app/common/kustomization.yaml",
+		"components:\n- ../overlay/vendor/github.com/someOrg/someRepo@v1.0.0/base\n")
+	expectContains(t, fSys, "/app/overlay/vendor/manifest.yaml", `bases:
+- commit: 0a013f3603b1ab3924b90ac5e38df7cc1cf4c187
+  dir: github.com/someOrg/someRepo@v1.0.0
+  ref: v1.0.0
+  repo: https://github.com/someOrg/someRepo.git
+files:
+- path: example.com/crds-21346e27.yaml
+`, "  url: https://example.com/patches/replicas.yaml\n")
+
+	// Vendoring again finds nothing remote,
+	// and keeps the manifest.
+	v, err = newVendorer(fSys, "/app/overlay", clone, fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = v.run()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if clones != 2 || fetches != 2 {
+		t.Fatalf("expected no more clones or fetches, got %d and %d",
+			clones, fetches)
+	}
+	expectContains(t, fSys, "/app/overlay/vendor/manifest.yaml",
+		"dir: github.com/someOrg/someRepo@v1.0.0")
+}
+
+func expectContains(
+	t *testing.T, fSys filesys.FileSystem, path string, expected ...string) {
+	t.Helper()
+	content, err := fSys.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(content), e) {
+			t.Errorf("expected %s to contain %q, got\n%s", path, e, content)
+		}
+	}
+}
@@ -122,7 +122,7 @@ func (o *nameReferenceTransformer) selectReferral(
 	for _, res := range referralCandidateSubset {
 		id := res.OrgId()
 		if id.IsSelected(&target) && res.GetOriginalName() == oldName {
-			matches := referralCandidates.GetMatchingResourcesByOriginalId(id.Equals)
+			matches := referralCandidates.GetAllByOriginalId(id)
 			// If there's more than one match, there's no way
 			// to know which one to pick, so emit error.
 			if len(matches) > 1 {
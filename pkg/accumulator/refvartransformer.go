@@ -5,6 +5,8 @@ package accumulator
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"sigs.k8s.io/kustomize/v3/api/resmap"
 	"sigs.k8s.io/kustomize/v3/api/transform"
@@ -17,6 +19,9 @@ type refVarTransformer struct {
 	replacementCounts map[string]int
 	fieldSpecs        []types.FieldSpec
 	mappingFunc       func(string) interface{}
+	// The resources holding references to
+	// undefined vars, by var name.
+	undefined map[string][]string
 }
 
 // newRefVarTransformer returns a new refVarTransformer
@@ -76,6 +81,14 @@ func (rv *refVarTransformer) replaceVars(in interface{}) (interface{}, error) {
 	}
 }
 
+func (rv *refVarTransformer) noteUndefined(name, id string) {
+	ids := rv.undefined[name]
+	if len(ids) > 0 && ids[len(ids)-1] == id {
+		return
+	}
+	rv.undefined[name] = append(ids, id)
+}
+
 // UnusedVars returns slice of Var names that were unused
 // after a Transform run.
 func (rv *refVarTransformer) UnusedVars() []string {
@@ -89,12 +102,33 @@ func (rv *refVarTransformer) UnusedVars() []string {
 	return unused
 }
 
+// UndefinedVars returns the names of vars referenced, but
+// not defined, after a Transform run, each followed by
+// the resources referencing it.
+func (rv *refVarTransformer) UndefinedVars() []string {
+	var result []string
+	for k, ids := range rv.undefined {
+		result = append(result,
+			fmt.Sprintf("%s (in %s)", k, strings.Join(ids, ", ")))
+	}
+	sort.Strings(result)
+	return result
+}
+
 // Transform replaces $(VAR) style variables with values.
 func (rv *refVarTransformer) Transform(m resmap.ResMap) error {
 	rv.replacementCounts = make(map[string]int)
-	rv.mappingFunc = expansion.MappingFuncFor(
+	rv.undefined = make(map[string][]string)
+	mapping := expansion.MappingFuncFor(
 		rv.replacementCounts, rv.varMap)
 	for _, res := range m.Resources() {
+		id := res.CurId().String()
+		rv.mappingFunc = func(name string) interface{} {
+			if _, ok := rv.varMap[name]; !ok {
+				rv.noteUndefined(name, id)
+			}
+			return mapping(name)
+		}
 		for _, fieldSpec := range rv.fieldSpecs {
 			if res.OrgId().IsSelected(&fieldSpec.Gvk) {
 				if err := transform.MutateField(
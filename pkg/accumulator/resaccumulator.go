@@ -4,13 +4,17 @@
 package accumulator
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/kustomize/v3/api/builtinconfig"
 	"sigs.k8s.io/kustomize/v3/api/resid"
 	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/trace"
 	"sigs.k8s.io/kustomize/v3/api/types"
 )
 
@@ -21,6 +25,16 @@ type ResAccumulator struct {
 	resMap  resmap.ResMap
 	tConfig *builtinconfig.TransformerConfig
 	varSet  types.VarSet
+	trace   *trace.Trace
+	root    string
+	// Values of vars given from outside the build.
+	varValues map[string]string
+	// If true, problems that are otherwise ignored
+	// or only logged are errors.
+	strict bool
+	// Problems found in a strict build, to be
+	// reported together when the build is done.
+	problems []string
 }
 
 func MakeEmptyAccumulator() *ResAccumulator {
@@ -31,6 +45,56 @@ func MakeEmptyAccumulator() *ResAccumulator {
 	return ra
 }
 
+// SetTrace arranges for the changes made by the
+// accumulator's own transformations to be recorded
+// in the given trace, as made in the given root.
+func (ra *ResAccumulator) SetTrace(t *trace.Trace, root string) {
+	ra.trace = t
+	ra.root = root
+}
+
+// DeepCopy returns a copy of the accumulator, whose
+// resources may be changed without changing those
+// of the original.
+func (ra *ResAccumulator) DeepCopy() *ResAccumulator {
+	return &ResAccumulator{
+		resMap:    ra.resMap.DeepCopy(),
+		tConfig:   ra.tConfig.DeepCopy(),
+		varSet:    ra.varSet.Copy(),
+		trace:     ra.trace,
+		root:      ra.root,
+		varValues: ra.varValues,
+		strict:    ra.strict,
+		problems:  append([]string(nil), ra.problems...),
+	}
+}
+
+// SetVarValues sets the values of vars given from
+// outside the build, e.g. on the command line.  They
+// take precedence over vars of the same name declared
+// in kustomizations.
+func (ra *ResAccumulator) SetVarValues(values map[string]string) {
+	ra.varValues = values
+}
+
+// SetStrict arranges for problems that are otherwise
+// ignored or only logged, e.g. references to undefined
+// vars, to be errors.
+func (ra *ResAccumulator) SetStrict(strict bool) {
+	ra.strict = strict
+}
+
+// AddProblem records a problem found in a strict build.
+func (ra *ResAccumulator) AddProblem(p string) {
+	ra.problems = append(ra.problems, p)
+}
+
+// Problems returns the problems recorded so far,
+// including those of merged accumulators.
+func (ra *ResAccumulator) Problems() []string {
+	return ra.problems
+}
+
 // ResMap returns a copy of the internal resMap.
 func (ra *ResAccumulator) ResMap() resmap.ResMap {
 	return ra.resMap.ShallowCopy()
@@ -64,13 +128,18 @@ func (ra *ResAccumulator) GetTransformerConfig() *builtinconfig.TransformerConfi
 func (ra *ResAccumulator) MergeVars(incoming []types.Var) error {
 	for _, v := range incoming {
 		targetId := resid.NewResIdWithNamespace(v.ObjRef.GVK(), v.ObjRef.Name, v.ObjRef.Namespace)
-		idMatcher := targetId.GvknEquals
+		var matched []*resource.Resource
 		if targetId.Namespace != "" || !targetId.IsNamespaceableKind() {
+			matched = ra.resMap.GetAllByOriginalId(targetId)
+		} else {
 			// Preserve backward compatibility. An empty namespace means
 			// wildcard search on the namespace hence we still use GvknEquals
-			idMatcher = targetId.Equals
+			for _, r := range ra.resMap.GetAllByGvk(targetId.Gvk) {
+				if r.OrgId().GvknEquals(targetId) {
+					matched = append(matched, r)
+				}
+			}
 		}
-		matched := ra.resMap.GetMatchingResourcesByOriginalId(idMatcher)
 		if len(matched) > 1 {
 			return fmt.Errorf(
 				"found %d resId matches for var %s "+
@@ -93,6 +162,7 @@ func (ra *ResAccumulator) MergeAccumulator(other *ResAccumulator) (err error) {
 	if err != nil {
 		return err
 	}
+	ra.problems = append(ra.problems, other.problems...)
 	return ra.varSet.MergeSet(other.varSet)
 }
 
@@ -123,6 +193,12 @@ func (ra *ResAccumulator) findVarValueFromResources(v types.Var) (interface{}, e
 func (ra *ResAccumulator) makeVarReplacementMap() (map[string]interface{}, error) {
 	result := map[string]interface{}{}
 	for _, v := range ra.Vars() {
+		if value, ok := ra.varValues[v.Name]; ok {
+			log.Printf(
+				"var '%s', declared as %v, is overridden by the value '%s'\n",
+				v.Name, v, value)
+			continue
+		}
 		s, err := ra.findVarValueFromResources(v)
 		if err != nil {
 			return nil, err
@@ -130,7 +206,9 @@ func (ra *ResAccumulator) makeVarReplacementMap() (map[string]interface{}, error
 
 		result[v.Name] = s
 	}
-
+	for name, value := range ra.varValues {
+		result[name] = value
+	}
 	return result, nil
 }
 
@@ -143,24 +221,62 @@ func (ra *ResAccumulator) ResolveVars() error {
 	if err != nil {
 		return err
 	}
-	if len(replacementMap) == 0 {
+	if len(replacementMap) == 0 && !ra.strict {
 		return nil
 	}
 	t := newRefVarTransformer(
 		replacementMap, ra.tConfig.VarReference)
-	err = ra.Transform(t)
+	err = ra.Transform(ra.trace.Wrap(ra.root, "RefVarTransformer", t))
+	if err != nil {
+		return err
+	}
 	if len(t.UnusedVars()) > 0 {
 		log.Printf(
 			"well-defined vars that were never replaced: %s\n",
 			strings.Join(t.UnusedVars(), ","))
 	}
-	return err
+	if !ra.strict {
+		return nil
+	}
+	var problems []string
+	if len(t.UndefinedVars()) > 0 {
+		problems = append(problems, "references to undefined vars: "+
+			strings.Join(t.UndefinedVars(), ", "))
+	}
+	if unused := ra.declaredVars(t.UnusedVars()); len(unused) > 0 {
+		sort.Strings(unused)
+		problems = append(problems, "vars never used: "+
+			strings.Join(unused, ", "))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// declaredVars returns those of the given var names that
+// are declared by a kustomization.  A value given only on
+// the command line, e.g. from a var file shared by many
+// builds, needn't be used by every build.
+func (ra *ResAccumulator) declaredVars(names []string) []string {
+	declared := map[string]bool{}
+	for _, v := range ra.Vars() {
+		declared[v.Name] = true
+	}
+	var result []string
+	for _, name := range names {
+		if declared[name] {
+			result = append(result, name)
+		}
+	}
+	return result
 }
 
 func (ra *ResAccumulator) FixBackReferences() (err error) {
 	if ra.tConfig.NameReference == nil {
 		return nil
 	}
-	return ra.Transform(newNameReferenceTransformer(
-		ra.tConfig.NameReference))
+	return ra.Transform(ra.trace.Wrap(
+		ra.root, "NameReferenceTransformer",
+		newNameReferenceTransformer(ra.tConfig.NameReference)))
 }
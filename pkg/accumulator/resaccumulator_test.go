@@ -133,6 +133,107 @@ func TestResolveVarsOneUnused(t *testing.T) {
 	}
 }
 
+func TestResolveVarsStrictUnused(t *testing.T) {
+	ra, _ := makeResAccumulator(t)
+	err := ra.MergeVars([]types.Var{
+		{
+			Name: "SERVICE_ONE",
+			ObjRef: types.Target{
+				Gvk:  resid.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendOne"},
+		},
+		{
+			Name: "SERVICE_UNUSED",
+			ObjRef: types.Target{
+				Gvk:  resid.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendTwo"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	ra.SetVarValues(map[string]string{"SERVICE_TWO": "fromFlag"})
+	ra.SetStrict(true)
+	err = ra.ResolveVars()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := "vars never used: SERVICE_UNUSED"
+	if err.Error() != expected {
+		t.Fatalf("expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestResolveVarsWithValues(t *testing.T) {
+	ra, _ := makeResAccumulator(t)
+	err := ra.MergeVars([]types.Var{
+		{
+			Name: "SERVICE_ONE",
+			ObjRef: types.Target{
+				Gvk:  resid.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendOne"},
+		},
+		{
+			// Would fail to resolve, but is overridden.
+			Name: "SERVICE_TWO",
+			ObjRef: types.Target{
+				Gvk:  resid.Gvk{Version: "v1", Kind: "Service"},
+				Name: "missing"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	ra.SetVarValues(map[string]string{"SERVICE_TWO": "fromFlag"})
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer func() {
+		log.SetOutput(os.Stderr)
+	}()
+	err = ra.ResolveVars()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	expectLog(t, buf, "var 'SERVICE_TWO', declared as")
+	expectLog(t, buf, "is overridden by the value 'fromFlag'")
+	c := getCommand(find("deploy1", ra.ResMap()))
+	if c != "myserver --somebackendService backendOne --yetAnother fromFlag" {
+		t.Fatalf("unexpected command: %s", c)
+	}
+}
+
+func TestResolveVarsStrictUndefined(t *testing.T) {
+	ra, _ := makeResAccumulator(t)
+	err := ra.MergeVars([]types.Var{
+		{
+			Name: "SERVICE_ONE",
+			ObjRef: types.Target{
+				Gvk:  resid.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendOne"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	err = ra.ResolveVars()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	ra, _ = makeResAccumulator(t)
+	ra.SetStrict(true)
+	err = ra.ResolveVars()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := "references to undefined vars: " +
+		"SERVICE_ONE (in apps_v1_Deployment|~X|deploy1), " +
+		"SERVICE_TWO (in apps_v1_Deployment|~X|deploy1)"
+	if err.Error() != expected {
+		t.Fatalf("expected '%s', got '%v'", expected, err)
+	}
+}
+
 func expectLog(t *testing.T, log bytes.Buffer, expect string) {
 	if !strings.Contains(log.String(), expect) {
 		t.Fatalf("expected log containing '%s', got '%s'", expect, log.String())
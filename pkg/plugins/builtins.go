@@ -27,6 +27,9 @@ const (
 	HashTransformer
 	InventoryTransformer
 	LegacyOrderTransformer
+	SortOrderTransformer
+	ReplacementTransformer
+	ExcludeTransformer
 )
 
 var stringToBuiltinPluginTypeMap map[string]BuiltinPluginType
@@ -72,4 +75,7 @@ var TransformerFactories = map[BuiltinPluginType]func() resmap.TransformerPlugin
 	HashTransformer:                builtin.NewHashTransformerPlugin,
 	InventoryTransformer:           builtin.NewInventoryTransformerPlugin,
 	LegacyOrderTransformer:         builtin.NewLegacyOrderTransformerPlugin,
+	SortOrderTransformer:           builtin.NewSortOrderTransformerPlugin,
+	ReplacementTransformer:         builtin.NewReplacementTransformerPlugin,
+	ExcludeTransformer:             builtin.NewExcludeTransformerPlugin,
 }
@@ -9,6 +9,7 @@ import (
 	"plugin"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/v3/api/ifc"
@@ -159,10 +160,18 @@ func (l *Loader) loadPlugin(resId resid.ResId) (resmap.Configurable, error) {
 // but the loaded .so files are in shared memory, so one will get
 // "this plugin already loaded" errors if the registry is maintained
 // as a Loader instance variable.  So make it a package variable.
-var registry = make(map[string]resmap.Configurable)
+// Bases may be accumulated concurrently, so registryMu guards
+// the registry, and is held while a .so file is loaded, so that
+// it's loaded once.
+var (
+	registry   = make(map[string]resmap.Configurable)
+	registryMu sync.Mutex
+)
 
 func (l *Loader) loadGoPlugin(id resid.ResId) (resmap.Configurable, error) {
 	regId := relativePluginPath(id)
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	if c, ok := registry[regId]; ok {
 		return copyPlugin(c), nil
 	}
@@ -72,3 +72,35 @@ func TestLoader(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestLoaderConcurrent(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"someteam.example.com", "v1", "SomeServiceGenerator")
+
+	rmF := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), nil)
+	pLdr := NewLoader(ActivePluginConfig(), rmF)
+
+	// Bases may be accumulated concurrently,
+	// loading the same plugins at once.
+	errs := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func() {
+			m, err := rmF.NewResMapFromBytes([]byte(someServiceGenerator))
+			if err == nil {
+				_, err = pLdr.LoadGenerators(
+					loadertest.NewFakeLoader("/foo"),
+					valtest_test.MakeFakeValidator(), m)
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func makeZip(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return buf.String()
+}
+
+func TestBaseInArchive(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteF("/app/bases/platform-1.4.zip", makeZip(t, map[string]string{
+		"base/kustomization.yaml": `
+resources:
+- deployment.yaml
+`,
+		"base/deployment.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 1
+`,
+		"overlays/prod/kustomization.yaml": `
+namePrefix: prod-
+resources:
+- ../../base
+`,
+	}))
+	th.WriteK("/app", `
+namespace: platform
+resources:
+- bases/platform-1.4.zip//overlays/prod
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-controller
+  namespace: platform
+spec:
+  replicas: 1
+`)
+}
@@ -409,6 +409,124 @@ spec:
 `)
 }
 
+func TestSharedPatchAllowListed(t *testing.T) {
+	th := kusttest_test.NewKustTestHarnessFull(
+		t, "/app/overlay",
+		loader.RestrictionAllowDirs(nil), plugins.DefaultPluginConfig())
+	writeSmallBase(th)
+	th.WriteK("/app/overlay", `
+allowDirs:
+- ../shared
+resources:
+- ../base
+patchesStrategicMerge:
+- ../shared/deployment-patch.yaml
+`)
+	th.WriteF("/app/shared/deployment-patch.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myDeployment
+spec:
+  replicas: 1000
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: myApp
+  name: a-myDeployment
+spec:
+  replicas: 1000
+  selector:
+    matchLabels:
+      app: myApp
+  template:
+    metadata:
+      labels:
+        app: myApp
+        backend: awesome
+    spec:
+      containers:
+      - image: whatever
+        name: whatever
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: myApp
+  name: a-myService
+spec:
+  ports:
+  - port: 7002
+  selector:
+    app: myApp
+    backend: bungie
+`)
+}
+
+func TestSharedPatchAllowListedRequiresFlag(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeSmallBase(th)
+	th.WriteK("/app/overlay", `
+allowDirs:
+- ../shared
+resources:
+- ../base
+patchesStrategicMerge:
+- ../shared/deployment-patch.yaml
+`)
+	th.WriteF("/app/shared/deployment-patch.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myDeployment
+spec:
+  replicas: 1000
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(
+		err.Error(),
+		"'/app/overlay' allows [/app/shared], "+
+			"which requires --load_restrictor allowlist") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// A base, which may be a vendored copy of a remote
+// base, may not allow directories.
+func TestSharedPatchAllowListedOnlyAtTop(t *testing.T) {
+	th := kusttest_test.NewKustTestHarnessFull(
+		t, "/app/overlay",
+		loader.RestrictionAllowDirs(nil), plugins.DefaultPluginConfig())
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+`)
+	th.WriteK("/app/base", `
+allowDirs:
+- /
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(
+		err.Error(),
+		"only the kustomization being built may allow directories") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
 func TestSmallOverlayJSONPatch(t *testing.T) {
 	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
 	writeSmallBase(th)
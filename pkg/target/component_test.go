@@ -0,0 +1,143 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeComponentBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+resources:
+- deployment.yaml
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1.0
+`)
+	th.WriteF("/app/components/monitoring/kustomization.yaml", `
+apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+resources:
+- servicemonitor.yaml
+patchesStrategicMerge:
+- sidecar.yaml
+`)
+	th.WriteF("/app/components/monitoring/servicemonitor.yaml", `
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: web
+`)
+	th.WriteF("/app/components/monitoring/sidecar.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: exporter
+        image: exporter:2.0
+`)
+	th.WriteF("/app/components/ha/kustomization.yaml", `
+kind: Component
+replicas:
+- name: web
+  count: 3
+`)
+}
+
+func TestComponentsPatchSiblingResources(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/prod")
+	writeComponentBase(th)
+	th.WriteK("/app/prod", `
+namePrefix: prod-
+resources:
+- ../base
+components:
+- ../components/monitoring
+- ../components/ha
+buildMetadata:
+- originAnnotations
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: ../base/deployment.yaml
+  name: prod-web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: exporter:2.0
+        name: exporter
+      - image: web:1.0
+        name: web
+---
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: ../components/monitoring/servicemonitor.yaml
+  name: prod-web
+`)
+}
+
+func TestComponentUnderResources(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/prod")
+	writeComponentBase(th)
+	th.WriteK("/app/prod", `
+resources:
+- ../components/ha
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(),
+		"'../components/ha' is a Component; list it under components") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestKustomizationUnderComponents(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/prod")
+	writeComponentBase(th)
+	th.WriteK("/app/prod", `
+components:
+- ../base
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(),
+		"'../base' is not a Component; list it under resources") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
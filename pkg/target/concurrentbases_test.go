@@ -0,0 +1,124 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+	"sigs.k8s.io/kustomize/v3/api/trace"
+)
+
+// writeBases writes n bases, each a kustomization holding a
+// ConfigMap, and a base of its own holding another, and
+// returns the resources entries naming them.
+func writeBases(th *kusttest_test.KustTestHarness, n int) string {
+	var entries []string
+	for i := 0; i < n; i++ {
+		dir := fmt.Sprintf("/app/b%d", i)
+		th.WriteK(dir, `
+namePrefix: p-
+resources:
+- inner
+- cm.yaml
+`)
+		th.WriteF(dir+"/cm.yaml", fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm%d
+`, i))
+		th.WriteK(dir+"/inner", `
+resources:
+- cm.yaml
+`)
+		th.WriteF(dir+"/inner/cm.yaml", fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: inner%d
+`, i))
+		entries = append(entries, fmt.Sprintf("- ../b%d", i))
+	}
+	return strings.Join(entries, "\n")
+}
+
+func TestConcurrentBasesMergeInOrder(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/overlay", `
+resources:
+`+writeBases(th, 12)+`
+- cm.yaml
+`)
+	th.WriteF("/app/overlay/cm.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: own
+`)
+	var expectedNames, expectedRoots []string
+	for i := 0; i < 12; i++ {
+		expectedNames = append(expectedNames,
+			fmt.Sprintf("p-inner%d", i), fmt.Sprintf("p-cm%d", i))
+		expectedRoots = append(expectedRoots,
+			fmt.Sprintf("/app/b%d/inner", i), fmt.Sprintf("/app/b%d", i))
+	}
+	expectedNames = append(expectedNames, "own")
+	expectedRoots = append(expectedRoots, "/app/overlay")
+
+	// Which base is done first varies; the result mustn't.
+	for n := 0; n < 5; n++ {
+		tr := trace.New()
+		kt := th.MakeKustTarget()
+		kt.SetTrace(tr)
+		m, err := kt.MakeCustomizedResMap()
+		if err != nil {
+			t.Fatalf("Err: %v", err)
+		}
+		var names []string
+		for _, r := range m.Resources() {
+			names = append(names, r.GetName())
+		}
+		if !reflect.DeepEqual(names, expectedNames) {
+			t.Fatalf("expected resources\n%v\nbut got\n%v", expectedNames, names)
+		}
+		var roots []string
+		for _, s := range tr.Steps {
+			if len(roots) == 0 || roots[len(roots)-1] != s.Root {
+				roots = append(roots, s.Root)
+			}
+		}
+		if !reflect.DeepEqual(roots, expectedRoots) {
+			t.Fatalf("expected steps in\n%v\nbut got\n%v", expectedRoots, roots)
+		}
+	}
+}
+
+func TestConcurrentBasesFirstErrorWins(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/overlay", `
+resources:
+`+writeBases(th, 8)+`
+`)
+	th.WriteK("/app/b2/inner", `
+resources:
+- missing2.yaml
+`)
+	th.WriteK("/app/b6/inner", `
+resources:
+- missing6.yaml
+`)
+	for n := 0; n < 5; n++ {
+		_, err := th.MakeKustTarget().MakeCustomizedResMap()
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if !strings.Contains(err.Error(), "missing2.yaml") {
+			t.Fatalf("expected error from the first listed base, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeExcludeBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+namePrefix: base-
+resources:
+- deployment.yaml
+- pdb.yaml
+- job.yaml
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`)
+	th.WriteF("/app/base/pdb.yaml", `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+`)
+	th.WriteF("/app/base/job.yaml", `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: smoke-test
+  labels:
+    purpose: test
+`)
+}
+
+func TestExclude(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeExcludeBase(th)
+	th.WriteK("/app/overlay", `
+namePrefix: prod-
+resources:
+- ../base
+exclude:
+- kind: PodDisruptionBudget
+  name: web
+- labelSelector: purpose=test
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-base-web
+`)
+}
+
+func TestExcludePicksNothing(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeExcludeBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+exclude:
+- kind: Job
+  name: smoke-tset
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(),
+		"exclude selector {kind: Job, name: smoke-tset} picks no resources") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
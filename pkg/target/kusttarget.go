@@ -16,8 +16,12 @@ import (
 	"github.com/pkg/errors"
 	"sigs.k8s.io/kustomize/v3/api/builtinconfig"
 	"sigs.k8s.io/kustomize/v3/api/ifc"
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/profile"
 	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/trace"
 	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/internal/kusterr"
 	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
 	"sigs.k8s.io/kustomize/v3/pkg/pgmconfig"
 	"sigs.k8s.io/kustomize/v3/pkg/plugins"
@@ -28,11 +32,33 @@ import (
 // KustTarget encapsulates the entirety of a kustomization build.
 type KustTarget struct {
 	kustomization *types.Kustomization
+	kustFileName  string
 	ldr           ifc.Loader
 	validator     ifc.Validator
 	rFactory      *resmap.Factory
 	tFactory      resmap.PatchFactory
 	pLdr          *plugins.Loader
+	// If true, record the origin of resources even if
+	// the kustomization doesn't ask for it.
+	originAnnotations bool
+	// If non-nil, record the changes made by transformers.
+	trace *trace.Trace
+	// If non-nil, record the time and memory
+	// taken by the stages of the build.
+	profile *profile.Profile
+	// If non-nil, customize the output as an overlay
+	// holding these fields would.
+	overlay *types.Kustomization
+	// Values of vars given from outside the build.
+	varValues map[string]string
+	// If true, fail on problems otherwise ignored or logged.
+	strict bool
+	// Bounds the bases accumulated concurrently;
+	// shared by all the targets of a build.
+	workers workerPool
+	// If true, one of the kustomization's transformers
+	// is a SortOrderTransformer.
+	sortsByTransformer bool
 }
 
 // NewKustTarget returns a new instance of KustTarget primed with a Loader.
@@ -42,7 +68,7 @@ func NewKustTarget(
 	rFactory *resmap.Factory,
 	tFactory resmap.PatchFactory,
 	pLdr *plugins.Loader) (*KustTarget, error) {
-	content, err := loadKustFile(ldr)
+	content, kustFileName, err := loadKustFile(ldr)
 	if err != nil {
 		return nil, err
 	}
@@ -59,13 +85,19 @@ func NewKustTarget(
 			"Failed to read kustomization file under %s:\n"+
 				strings.Join(errs, "\n"), ldr.Root())
 	}
+	ldr, err = ldr.Allow(k.AllowDirs)
+	if err != nil {
+		return nil, err
+	}
 	return &KustTarget{
 		kustomization: &k,
+		kustFileName:  kustFileName,
 		ldr:           ldr,
 		validator:     validator,
 		rFactory:      rFactory,
 		tFactory:      tFactory,
 		pLdr:          pLdr,
+		workers:       newWorkerPool(),
 	}, nil
 }
 
@@ -81,26 +113,28 @@ func commaOr(q []string) string {
 	return strings.Join(q[:len(q)-1], ", ") + " or " + q[len(q)-1]
 }
 
-func loadKustFile(ldr ifc.Loader) ([]byte, error) {
+func loadKustFile(ldr ifc.Loader) ([]byte, string, error) {
 	var content []byte
+	var name string
 	match := 0
 	for _, kf := range pgmconfig.RecognizedKustomizationFileNames() {
 		c, err := ldr.Load(kf)
 		if err == nil {
 			match += 1
 			content = c
+			name = kf
 		}
 	}
 	switch match {
 	case 0:
-		return nil, fmt.Errorf(
+		return nil, "", fmt.Errorf(
 			"unable to find one of %v in directory '%s'",
 			commaOr(quoted(pgmconfig.RecognizedKustomizationFileNames())),
 			ldr.Root())
 	case 1:
-		return content, nil
+		return content, name, nil
 	default:
-		return nil, fmt.Errorf(
+		return nil, "", fmt.Errorf(
 			"Found multiple kustomization files under: %s\n", ldr.Root())
 	}
 }
@@ -131,24 +165,42 @@ func (kt *KustTarget) makeCustomizedResMap(
 	if err != nil {
 		return nil, err
 	}
+	err = kt.accumulateOverlay(ra)
+	if err != nil {
+		return nil, err
+	}
+	return kt.finishResMap(ra, garbagePolicy)
+}
 
-	// The following steps must be done last, not as part of
-	// the recursion implicit in AccumulateTarget.
-
-	err = kt.addHashesToNames(ra)
+// finishResMap does the steps that must be done last,
+// not as part of the recursion implicit in AccumulateTarget.
+func (kt *KustTarget) finishResMap(
+	ra *accumulator.ResAccumulator,
+	garbagePolicy types.GarbagePolicy) (resmap.ResMap, error) {
+	err := kt.addHashesToNames(ra)
 	if err != nil {
 		return nil, err
 	}
 
 	// Given that names have changed (prefixs/suffixes added),
 	// fix all the back references to those names.
-	err = ra.FixBackReferences()
+	err = kt.profile.Time(
+		kt.ldr.Root(), "FixBackReferences", ra.FixBackReferences)
 	if err != nil {
 		return nil, err
 	}
 
 	// With all the back references fixed, it's OK to resolve Vars.
-	err = ra.ResolveVars()
+	ra.SetVarValues(kt.varValues)
+	ra.SetStrict(kt.strict)
+	err = kt.profile.Time(kt.ldr.Root(), "ResolveVars", ra.ResolveVars)
+	if err != nil {
+		if !kt.strict {
+			return nil, err
+		}
+		kt.noteProblem(ra, err.Error())
+	}
+	err = problemsError(ra)
 	if err != nil {
 		return nil, err
 	}
@@ -158,9 +210,41 @@ func (kt *KustTarget) makeCustomizedResMap(
 		return nil, err
 	}
 
+	kt.annotateOrigins(ra)
+
+	err = kt.sortResources(ra)
+	if err != nil {
+		return nil, err
+	}
+
 	return ra.ResMap(), nil
 }
 
+// SortsOutput is true if the kustomization specifies
+// its own ordering of the resources it emits, with
+// sortOptions or with a SortOrderTransformer in its
+// transformers.  The latter is known only once the
+// transformers are loaded, i.e. after a build.
+func (kt *KustTarget) SortsOutput() bool {
+	return kt.kustomization.SortOptions != nil || kt.sortsByTransformer
+}
+
+func (kt *KustTarget) sortResources(
+	ra *accumulator.ResAccumulator) error {
+	// A SortOrderTransformer in the transformers
+	// has sorted the resources already.
+	if kt.kustomization.SortOptions == nil {
+		return nil
+	}
+	p := builtin.NewSortOrderTransformerPlugin()
+	err := kt.configureBuiltinPlugin(
+		p, kt.kustomization.SortOptions, plugins.SortOrderTransformer)
+	if err != nil {
+		return err
+	}
+	return ra.Transform(p)
+}
+
 func (kt *KustTarget) addHashesToNames(
 	ra *accumulator.ResAccumulator) error {
 	p := builtin.NewHashTransformerPlugin()
@@ -168,7 +252,8 @@ func (kt *KustTarget) addHashesToNames(
 	if err != nil {
 		return err
 	}
-	return ra.Transform(p)
+	return ra.Transform(kt.traced(plugins.HashTransformer.String(),
+		kt.profiled("hash", p)))
 }
 
 func (kt *KustTarget) computeInventory(
@@ -212,44 +297,71 @@ func (kt *KustTarget) shouldAddHashSuffixesToGeneratedResources() bool {
 func (kt *KustTarget) AccumulateTarget() (
 	ra *accumulator.ResAccumulator, err error) {
 	ra = accumulator.MakeEmptyAccumulator()
-	err = kt.accumulateResources(ra, kt.kustomization.Resources)
+	ra.SetTrace(kt.trace, kt.ldr.Root())
+	err = kt.profile.Time(kt.ldr.Root(), "accumulate", func() error {
+		return kt.accumulateTarget(ra)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ra, nil
+}
+
+// accumulateTarget accumulates the kustomization's
+// resources and components into the given accumulator,
+// then runs its generators and transformers over
+// everything accumulated.  For a kustomization that
+// isn't a component, the accumulator starts empty.
+func (kt *KustTarget) accumulateTarget(ra *accumulator.ResAccumulator) error {
+	err := kt.accumulateResources(ra, kt.kustomization.Resources)
+	if err != nil {
+		return errors.Wrap(err, "accumulating resources")
+	}
+	err = kt.accumulateComponents(ra, kt.kustomization.Components)
 	if err != nil {
-		return nil, errors.Wrap(err, "accumulating resources")
+		return errors.Wrap(err, "accumulating components")
 	}
 	tConfig, err := builtinconfig.MakeTransformerConfig(
 		kt.ldr, kt.kustomization.Configurations)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	err = ra.MergeConfig(tConfig)
 	if err != nil {
-		return nil, errors.Wrapf(
+		return errors.Wrapf(
 			err, "merging config %v", tConfig)
 	}
 	crdTc, err := accumulator.LoadConfigFromCRDs(kt.ldr, kt.kustomization.Crds)
 	if err != nil {
-		return nil, errors.Wrapf(
+		return errors.Wrapf(
 			err, "loading CRDs %v", kt.kustomization.Crds)
 	}
 	err = ra.MergeConfig(crdTc)
 	if err != nil {
-		return nil, errors.Wrapf(
+		return errors.Wrapf(
 			err, "merging CRDs %v", crdTc)
 	}
 	err = kt.runGenerators(ra)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	err = kt.runTransformers(ra)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	err = ra.MergeVars(kt.kustomization.Vars)
 	if err != nil {
-		return nil, errors.Wrapf(
+		return errors.Wrapf(
 			err, "merging vars %v", kt.kustomization.Vars)
 	}
-	return ra, nil
+	return nil
+}
+
+// IsComponent is true if the kustomization is of kind
+// Component, meaning it's meant to be listed in another
+// kustomization's components field.
+func (kt *KustTarget) IsComponent() bool {
+	return kt.kustomization.Kind == types.ComponentKind
 }
 
 func (kt *KustTarget) runGenerators(
@@ -284,13 +396,22 @@ func (kt *KustTarget) configureExternalGenerators() ([]resmap.Generator, error)
 	if err != nil {
 		return nil, err
 	}
-	return kt.pLdr.LoadGenerators(kt.ldr, kt.validator, ra.ResMap())
+	configs := ra.ResMap()
+	gs, err := kt.pLdr.LoadGenerators(kt.ldr, kt.validator, configs)
+	if err != nil {
+		return nil, err
+	}
+	for i, config := range configs.Resources() {
+		gs[i] = kt.profile.WrapGenerator(
+			kt.ldr.Root(), "generator "+config.CurId().String(),
+			kt.withExternalGeneratorOrigin(gs[i], config))
+	}
+	return gs, nil
 }
 
 func (kt *KustTarget) runTransformers(ra *accumulator.ResAccumulator) error {
 	var r []resmap.Transformer
-	tConfig := ra.GetTransformerConfig()
-	lts, err := kt.configureBuiltinTransformers(tConfig)
+	lts, err := kt.configureBuiltinTransformers(ra)
 	if err != nil {
 		return err
 	}
@@ -310,59 +431,197 @@ func (kt *KustTarget) configureExternalTransformers() ([]resmap.Transformer, err
 	if err != nil {
 		return nil, err
 	}
-	return kt.pLdr.LoadTransformers(kt.ldr, kt.validator, ra.ResMap())
+	configs := ra.ResMap()
+	ts, err := kt.pLdr.LoadTransformers(kt.ldr, kt.validator, configs)
+	if err != nil {
+		return nil, err
+	}
+	for i, config := range configs.Resources() {
+		if config.GetKind() == plugins.SortOrderTransformer.String() {
+			kt.sortsByTransformer = true
+		}
+		name := config.CurId().String()
+		ts[i] = kt.traced(name, kt.profiled("transformer "+name, ts[i]))
+	}
+	return ts, nil
+}
+
+// SetVarValues sets the values of vars given from outside
+// the build, e.g. on the command line.  They may define new
+// vars, or override vars declared in kustomizations.
+func (kt *KustTarget) SetVarValues(values map[string]string) {
+	kt.varValues = values
+}
+
+// SetStrict arranges for the build to fail on problems
+// that are otherwise ignored or only logged, e.g.
+// references to undefined or unused vars, or patches
+// and images entries that change nothing.  All the
+// problems found are reported together.
+func (kt *KustTarget) SetStrict(strict bool) {
+	kt.strict = strict
+}
+
+// SetTrace arranges for the changes made by each
+// transformer to be recorded in the given trace.
+func (kt *KustTarget) SetTrace(t *trace.Trace) {
+	kt.trace = t
+}
+
+// traced wraps the transformer so that
+// its changes are recorded in the trace.
+func (kt *KustTarget) traced(
+	name string, t resmap.Transformer) resmap.Transformer {
+	return kt.trace.Wrap(kt.ldr.Root(), name, t)
+}
+
+// SetProfile arranges for the time and memory taken by
+// the stages of the build to be recorded in the given profile.
+func (kt *KustTarget) SetProfile(p *profile.Profile) {
+	kt.profile = p
+}
+
+// profiled wraps the transformer so that the time and
+// memory it takes are recorded in the profile.
+func (kt *KustTarget) profiled(
+	stage string, t resmap.Transformer) resmap.Transformer {
+	return kt.profile.Wrap(kt.ldr.Root(), stage, t)
 }
 
 // accumulateResources fills the given resourceAccumulator
 // with resources read from the given list of paths.
 func (kt *KustTarget) accumulateResources(
 	ra *accumulator.ResAccumulator, paths []string) error {
-	for _, path := range paths {
-		ldr, err := kt.ldr.New(path)
-		if err == nil {
-			err = kt.accumulateDirectory(ra, ldr, path)
+	for i, b := range kt.accumulateBases(paths) {
+		if b == nil {
+			err := kt.accumulateFile(ra, paths[i])
 			if err != nil {
 				return err
 			}
-		} else {
-			err2 := kt.accumulateFile(ra, path)
-			if err2 != nil {
-				// Log ldr.New() error to highlight git failures.
-				log.Print(err.Error())
-				return err2
+			continue
+		}
+		if b.newErr == nil {
+			err := kt.mergeBase(ra, b)
+			if err != nil {
+				return err
 			}
+			continue
+		}
+		err := kt.accumulateFile(ra, b.path)
+		if err != nil {
+			// Log ldr.New() error to highlight git failures.
+			log.Print(b.newErr.Error())
+			return err
 		}
 	}
 	return nil
 }
 
+// accumulateDirectory accumulates the kustomization in
+// the loader's root, recording its transformers' changes
+// in the given trace.
 func (kt *KustTarget) accumulateDirectory(
-	ra *accumulator.ResAccumulator, ldr ifc.Loader, path string) error {
+	ldr ifc.Loader, path string,
+	t *trace.Trace) (*accumulator.ResAccumulator, error) {
 	defer ldr.Cleanup()
 	subKt, err := NewKustTarget(
 		ldr, kt.validator, kt.rFactory, kt.tFactory, kt.pLdr)
 	if err != nil {
-		return errors.Wrapf(err, "couldn't make target for path '%s'", path)
+		return nil, errors.Wrapf(err, "couldn't make target for path '%s'", path)
 	}
+	if subKt.IsComponent() {
+		return nil, fmt.Errorf(
+			"'%s' is a %s; list it under components, not resources",
+			path, types.ComponentKind)
+	}
+	if kt.tracksOrigin() {
+		subKt.EnableOriginAnnotations()
+	}
+	subKt.SetTrace(t)
+	subKt.SetProfile(kt.profile)
+	subKt.SetStrict(kt.strict)
+	subKt.workers = kt.workers
 	subRa, err := subKt.AccumulateTarget()
 	if err != nil {
-		return errors.Wrapf(
+		return nil, errors.Wrapf(
 			err, "recursed accumulation of path '%s'", path)
 	}
-	err = ra.MergeAccumulator(subRa)
+	prependOriginPaths(subRa, path)
+	return subRa, nil
+}
+
+// mergeBase merges an accumulated base
+// into the given accumulator.
+func (kt *KustTarget) mergeBase(
+	ra *accumulator.ResAccumulator, b *base) error {
+	if b.err != nil {
+		return b.err
+	}
+	kt.trace.Append(b.trace)
+	err := ra.MergeAccumulator(b.ra)
 	if err != nil {
 		return errors.Wrapf(
-			err, "recursed merging from path '%s'", path)
+			err, "recursed merging from path '%s'", b.path)
 	}
 	return nil
 }
 
+// accumulateComponents applies the components at the
+// given paths, in order, to the given accumulator.
+func (kt *KustTarget) accumulateComponents(
+	ra *accumulator.ResAccumulator, paths []string) error {
+	for _, path := range paths {
+		ldr, err := kt.ldr.New(path)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't load component '%s'", path)
+		}
+		err = kt.accumulateComponent(ra, ldr, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (kt *KustTarget) accumulateComponent(
+	ra *accumulator.ResAccumulator, ldr ifc.Loader, path string) error {
+	defer ldr.Cleanup()
+	subKt, err := NewKustTarget(
+		ldr, kt.validator, kt.rFactory, kt.tFactory, kt.pLdr)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't make target for component '%s'", path)
+	}
+	if !subKt.IsComponent() {
+		return fmt.Errorf(
+			"'%s' is not a %s; list it under resources, not components",
+			path, types.ComponentKind)
+	}
+	if kt.tracksOrigin() {
+		subKt.EnableOriginAnnotations()
+	}
+	subKt.SetTrace(kt.trace)
+	subKt.SetProfile(kt.profile)
+	subKt.SetStrict(kt.strict)
+	subKt.workers = kt.workers
+	before := accumulatedResources(ra)
+	err = kt.profile.Time(ldr.Root(), "accumulate component", func() error {
+		return subKt.accumulateTarget(ra)
+	})
+	if err != nil {
+		return errors.Wrapf(
+			err, "recursed accumulation of component '%s'", path)
+	}
+	prependNewOriginPaths(ra, before, path)
+	return nil
+}
+
 func (kt *KustTarget) accumulateFile(
 	ra *accumulator.ResAccumulator, path string) error {
-	resources, err := kt.rFactory.FromFile(kt.ldr, path)
+	resources, err := kt.resourcesFromFile(path)
 	if err != nil {
 		return errors.Wrapf(err, "accumulating resources from '%s'", path)
 	}
+	kt.setFileOrigins(resources, path)
 	err = ra.AppendAll(resources)
 	if err != nil {
 		return errors.Wrapf(err, "merging resources from '%s'", path)
@@ -370,6 +629,23 @@ func (kt *KustTarget) accumulateFile(
 	return nil
 }
 
+// resourcesFromFile reads the resources in the file at
+// the path, which may be the url of a remote file.
+func (kt *KustTarget) resourcesFromFile(path string) (resmap.ResMap, error) {
+	if !loader.IsRemoteFile(path) {
+		return kt.rFactory.FromFile(kt.ldr, path)
+	}
+	content, err := kt.ldr.LoadRemoteFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := kt.rFactory.NewResMapFromBytes(content)
+	if err != nil {
+		return nil, kusterr.Handler(err, path)
+	}
+	return m, nil
+}
+
 func (kt *KustTarget) configureBuiltinPlugin(
 	p resmap.Configurable, c interface{}, bpt plugins.BuiltinPluginType) (err error) {
 	var y []byte
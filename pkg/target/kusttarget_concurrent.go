@@ -0,0 +1,103 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"runtime"
+	"sync"
+
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/trace"
+	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
+)
+
+// The directories listed in the resources of a
+// kustomization, i.e. its bases, are independent of one
+// another until their resources are merged, so they're
+// accumulated concurrently, e.g. to clone several remote
+// bases at once.  The results are merged in the order the
+// bases are listed, so the output of a build doesn't
+// depend on which base is accumulated first.
+
+// maxConcurrentBases is the most bases that a build
+// accumulates concurrently, beside the goroutine that
+// runs the build.  It's at least 4, even with fewer
+// CPUs, as accumulating a remote base is mostly
+// waiting for its clone.
+var maxConcurrentBases = func() int {
+	if n := runtime.NumCPU(); n > 4 {
+		return n
+	}
+	return 4
+}()
+
+// workerPool bounds the goroutines accumulating bases
+// across a whole build, bases of bases included.
+type workerPool chan struct{}
+
+func newWorkerPool() workerPool {
+	return make(workerPool, maxConcurrentBases)
+}
+
+// run runs f in a new goroutine if the pool has room for
+// one, or else runs f before returning.  Since a goroutine
+// never waits for room in the pool, one that waits for the
+// bases it started can't deadlock with them.
+func (p workerPool) run(f func()) {
+	select {
+	case p <- struct{}{}:
+		go func() {
+			defer func() { <-p }()
+			f()
+		}()
+	default:
+		f()
+	}
+}
+
+// base holds the result of accumulating one entry of
+// the resources field, if the entry is a directory.
+type base struct {
+	path string
+	// newErr is the error making a loader at the path,
+	// meaning the entry is not a directory, but may be
+	// a file.
+	newErr error
+	ra     *accumulator.ResAccumulator
+	err    error
+	// trace holds the steps of the base's transformers,
+	// recorded apart from the build's trace until the base
+	// is merged, so that they're recorded in order.
+	trace *trace.Trace
+}
+
+// accumulateBases accumulates, concurrently, those of the
+// given paths that aren't remote files, returning a base
+// for each, or nil for a remote file, once all are done.
+func (kt *KustTarget) accumulateBases(paths []string) []*base {
+	result := make([]*base, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		if loader.IsRemoteFile(path) {
+			continue
+		}
+		b := &base{path: path}
+		if kt.trace != nil {
+			b.trace = trace.New()
+		}
+		result[i] = b
+		wg.Add(1)
+		kt.workers.run(func() {
+			defer wg.Done()
+			ldr, err := kt.ldr.New(b.path)
+			if err != nil {
+				b.newErr = err
+				return
+			}
+			b.ra, b.err = kt.accumulateDirectory(ldr, b.path, b.trace)
+		})
+	}
+	wg.Wait()
+	return result
+}
@@ -5,8 +5,10 @@ package target
 
 import (
 	"sigs.k8s.io/kustomize/v3/api/builtinconfig"
+	"sigs.k8s.io/kustomize/v3/api/loader"
 	"sigs.k8s.io/kustomize/v3/api/resmap"
 	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
 	"sigs.k8s.io/kustomize/v3/pkg/plugins"
 )
 
@@ -36,15 +38,21 @@ func (kt *KustTarget) configureBuiltinGenerators() (
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, r...)
+		for _, g := range r {
+			result = append(result, kt.profile.WrapGenerator(
+				kt.ldr.Root(), "generator "+bpt.String(),
+				kt.withBuiltinGeneratorOrigin(g, bpt)))
+		}
 	}
 	return result, nil
 }
 
 func (kt *KustTarget) configureBuiltinTransformers(
-	tc *builtinconfig.TransformerConfig) (
+	ra *accumulator.ResAccumulator) (
 	result []resmap.Transformer, err error) {
+	tc := ra.GetTransformerConfig()
 	for _, bpt := range []plugins.BuiltinPluginType{
+		plugins.ExcludeTransformer,
 		plugins.PatchStrategicMergeTransformer,
 		plugins.PatchTransformer,
 		plugins.NamespaceTransformer,
@@ -54,13 +62,18 @@ func (kt *KustTarget) configureBuiltinTransformers(
 		plugins.PatchJson6902Transformer,
 		plugins.ReplicaCountTransformer,
 		plugins.ImageTagTransformer,
+		plugins.ReplacementTransformer,
 	} {
 		r, err := transformerConfigurators[bpt](
 			kt, bpt, plugins.TransformerFactories[bpt], tc)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, r...)
+		for i, t := range r {
+			result = append(result, kt.traced(
+				bpt.String(), kt.strictChecked(ra, bpt, i,
+					kt.profiled("transformer "+bpt.String(), t))))
+		}
 	}
 	return result, nil
 }
@@ -194,6 +207,14 @@ var transformerConfigurators = map[plugins.BuiltinPluginType]func(
 			c.Target = pc.Target
 			c.Patch = pc.Patch
 			c.Path = pc.Path
+			if c.Patch == "" && loader.IsRemoteFile(c.Path) {
+				// The plugin loads only local files.
+				content, err := kt.ldr.LoadRemoteFile(c.Path)
+				if err != nil {
+					return nil, err
+				}
+				c.Patch, c.Path = string(content), ""
+			}
 			p := f()
 			err = kt.configureBuiltinPlugin(p, c, bpt)
 			if err != nil {
@@ -294,4 +315,40 @@ var transformerConfigurators = map[plugins.BuiltinPluginType]func(
 		}
 		return
 	},
+	plugins.ReplacementTransformer: func(
+		kt *KustTarget, bpt plugins.BuiltinPluginType, f tFactory, _ *builtinconfig.TransformerConfig) (
+		result []resmap.Transformer, err error) {
+		if len(kt.kustomization.Replacements) == 0 {
+			return
+		}
+		var c struct {
+			Replacements []types.Replacement
+		}
+		c.Replacements = kt.kustomization.Replacements
+		p := f()
+		err = kt.configureBuiltinPlugin(p, c, bpt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+		return
+	},
+	plugins.ExcludeTransformer: func(
+		kt *KustTarget, bpt plugins.BuiltinPluginType, f tFactory, _ *builtinconfig.TransformerConfig) (
+		result []resmap.Transformer, err error) {
+		if len(kt.kustomization.Exclude) == 0 {
+			return
+		}
+		var c struct {
+			Exclude []types.Selector
+		}
+		c.Exclude = kt.kustomization.Exclude
+		p := f()
+		err = kt.configureBuiltinPlugin(p, c, bpt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+		return
+	},
 }
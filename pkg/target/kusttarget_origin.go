@@ -0,0 +1,149 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/v3/api/loader"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+)
+
+// Functions dedicated to recording the origin of
+// resources, i.e. the file or generator each resource
+// came from.
+//
+// Origins are recorded on resources as they are read
+// or generated, with paths relative to the root of the
+// kustomization doing the reading.  As accumulations
+// are merged upward, the paths are extended so that,
+// by the end of the build, they are relative to the
+// root of the build.  Only then are origins written
+// to annotations, so that no transformer sees them.
+
+// EnableOriginAnnotations requests that the resources
+// in the build output be annotated with their origin,
+// as if the kustomization had asked for it.
+func (kt *KustTarget) EnableOriginAnnotations() {
+	kt.originAnnotations = true
+}
+
+func (kt *KustTarget) tracksOrigin() bool {
+	return kt.originAnnotations ||
+		kt.kustomization.HasBuildMetadata(types.OriginAnnotations)
+}
+
+// repoOrigin returns an origin for the given path
+// relative to the root, accounting for the root
+// perhaps being in a cloned repository.
+func (kt *KustTarget) repoOrigin(path string) *resource.Origin {
+	if loader.IsRemoteFile(path) {
+		return &resource.Origin{Path: path}
+	}
+	repo, ref, dir := kt.ldr.Repo()
+	if repo != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return &resource.Origin{Path: path, Repo: repo, Ref: ref}
+}
+
+func (kt *KustTarget) setFileOrigins(m resmap.ResMap, path string) {
+	if !kt.tracksOrigin() {
+		return
+	}
+	for i, r := range m.Resources() {
+		o := kt.repoOrigin(path)
+		o.Index = i
+		r.SetOrigin(o)
+	}
+}
+
+func (kt *KustTarget) withBuiltinGeneratorOrigin(
+	g resmap.Generator, bpt plugins.BuiltinPluginType) resmap.Generator {
+	if !kt.tracksOrigin() {
+		return g
+	}
+	o := kt.repoOrigin(kt.kustFileName)
+	o.ConfiguredIn, o.Path = o.Path, ""
+	o.ConfiguredBy = plugins.BuiltinPluginApiVersion + "/" + bpt.String()
+	return &originGenerator{Generator: g, origin: o}
+}
+
+func (kt *KustTarget) withExternalGeneratorOrigin(
+	g resmap.Generator, config *resource.Resource) resmap.Generator {
+	if !kt.tracksOrigin() || config.GetOrigin() == nil {
+		return g
+	}
+	o := *config.GetOrigin()
+	o.ConfiguredIn, o.Path, o.Index = o.Path, "", 0
+	gvk := config.GetGvk()
+	o.ConfiguredBy = gvk.Version + "/" + gvk.Kind
+	if gvk.Group != "" {
+		o.ConfiguredBy = gvk.Group + "/" + o.ConfiguredBy
+	}
+	return &originGenerator{Generator: g, origin: &o}
+}
+
+// originGenerator sets an origin on all the
+// resources made by the generator it wraps.
+type originGenerator struct {
+	resmap.Generator
+	origin *resource.Origin
+}
+
+func (g *originGenerator) Generate() (resmap.ResMap, error) {
+	m, err := g.Generator.Generate()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range m.Resources() {
+		r.SetOrigin(g.origin)
+	}
+	return m, nil
+}
+
+// prependOriginPaths makes the origin paths of the
+// accumulated resources relative to the parent of
+// the kustomization that accumulated them.
+func prependOriginPaths(ra *accumulator.ResAccumulator, dir string) {
+	for _, r := range ra.ResMap().Resources() {
+		if o := r.GetOrigin(); o != nil {
+			r.SetOrigin(o.Prepend(dir))
+		}
+	}
+}
+
+// accumulatedResources returns the set of resources
+// accumulated so far, so that prependNewOriginPaths
+// can tell them apart from those a component adds.
+func accumulatedResources(ra *accumulator.ResAccumulator) map[*resource.Resource]bool {
+	result := map[*resource.Resource]bool{}
+	for _, r := range ra.ResMap().Resources() {
+		result[r] = true
+	}
+	return result
+}
+
+// prependNewOriginPaths is like prependOriginPaths,
+// but leaves alone the resources that were already
+// accumulated before a component ran.
+func prependNewOriginPaths(
+	ra *accumulator.ResAccumulator,
+	before map[*resource.Resource]bool, dir string) {
+	for _, r := range ra.ResMap().Resources() {
+		if o := r.GetOrigin(); o != nil && !before[r] {
+			r.SetOrigin(o.Prepend(dir))
+		}
+	}
+}
+
+func (kt *KustTarget) annotateOrigins(ra *accumulator.ResAccumulator) {
+	for _, r := range ra.ResMap().Resources() {
+		r.SetOriginAnnotation()
+	}
+}
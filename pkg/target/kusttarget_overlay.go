@@ -0,0 +1,48 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
+)
+
+// SetOverlay arranges for the output of the kustomization
+// to be customized by the given one, which holds no resources,
+// exactly as an overlay holding its fields, and listing the
+// kustomization as its only resource, would customize it.
+// Files it names, e.g. in its generators, are relative to
+// the kustomization.
+func (kt *KustTarget) SetOverlay(k *types.Kustomization) {
+	kt.overlay = k
+}
+
+// overlayTarget returns a target whose kustomization holds
+// the given fields, for customizing the output of this target
+// as an overlay would.
+func (kt *KustTarget) overlayTarget(k *types.Kustomization) *KustTarget {
+	ok := *k
+	if ok.GeneratorOptions == nil {
+		ok.GeneratorOptions = kt.kustomization.GeneratorOptions
+	}
+	ok.BuildMetadata = kt.kustomization.BuildMetadata
+	okt := *kt
+	okt.kustomization = &ok
+	okt.overlay = nil
+	return &okt
+}
+
+// accumulateOverlay applies the overlay set by SetOverlay,
+// if any, to the accumulated resources.
+func (kt *KustTarget) accumulateOverlay(ra *accumulator.ResAccumulator) error {
+	if kt.overlay == nil {
+		return nil
+	}
+	err := kt.overlayTarget(kt.overlay).accumulateTarget(ra)
+	if err != nil {
+		return errors.Wrap(err, "applying overrides")
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/kustomize/v3/pkg/accumulator"
+	"sigs.k8s.io/kustomize/v3/pkg/plugins"
+)
+
+// In a strict build, the entries of a kustomization
+// that have no effect, e.g. a patch that matches no
+// resource or changes nothing, are problems.  They are
+// recorded in the accumulator as they're found, and
+// reported together, each with the path of the
+// kustomization holding it, once the build is done.
+
+// strictTransformer runs a transformer, then
+// checks what it did to the resources.
+type strictTransformer struct {
+	t resmap.Transformer
+	// check, if not nil, returns the problems with the
+	// transformation of the resources, given as they
+	// were before it.
+	check func(before, after resmap.ResMap) []string
+	// failed, if not nil, returns the problems with the
+	// kustomization entry that explain an error from the
+	// transformer, e.g. a patch target that is missing,
+	// so that the build goes on to find any others.
+	failed func(before resmap.ResMap, err error) []string
+	note   func(problem string)
+}
+
+func (t *strictTransformer) Transform(m resmap.ResMap) error {
+	before := m.DeepCopy()
+	err := t.t.Transform(m)
+	if err != nil {
+		if t.failed == nil {
+			return err
+		}
+		problems := t.failed(before, err)
+		if len(problems) == 0 {
+			return err
+		}
+		for _, p := range problems {
+			t.note(p)
+		}
+		return nil
+	}
+	if t.check == nil {
+		return nil
+	}
+	for _, p := range t.check(before, m) {
+		t.note(p)
+	}
+	return nil
+}
+
+// kustFilePath returns the path of the kustomization file.
+func (kt *KustTarget) kustFilePath() string {
+	return filepath.Join(kt.ldr.Root(), kt.kustFileName)
+}
+
+// noteProblem records, in a strict build,
+// a problem with this target's kustomization.
+func (kt *KustTarget) noteProblem(
+	ra *accumulator.ResAccumulator, problem string) {
+	ra.AddProblem(fmt.Sprintf("%s: %s", kt.kustFilePath(), problem))
+}
+
+// problemsError returns an error listing the problems
+// recorded by a strict build, or nil if there are none.
+func problemsError(ra *accumulator.ResAccumulator) error {
+	problems := ra.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"strict build found %d problem(s):\n  %s",
+		len(problems), strings.Join(problems, "\n  "))
+}
+
+// strictChecked wraps the i'th transformer of the given
+// builtin type, in a strict build, so that it notes the
+// problems with the kustomization entry it came from.
+func (kt *KustTarget) strictChecked(
+	ra *accumulator.ResAccumulator,
+	bpt plugins.BuiltinPluginType, i int,
+	t resmap.Transformer) resmap.Transformer {
+	if !kt.strict {
+		return t
+	}
+	var check func(before, after resmap.ResMap) []string
+	var failed func(before resmap.ResMap, err error) []string
+	switch bpt {
+	case plugins.PatchStrategicMergeTransformer:
+		check = kt.checkPatchesStrategicMerge
+		failed = func(before resmap.ResMap, _ error) []string {
+			return kt.checkPatchesStrategicMerge(before, nil)
+		}
+	case plugins.PatchTransformer:
+		check = func(before, after resmap.ResMap) []string {
+			return kt.checkPatch(i, before, after)
+		}
+	case plugins.PatchJson6902Transformer:
+		what := describeEntry(
+			"patchesJson6902", i, kt.kustomization.PatchesJson6902[i].Path)
+		check = func(before, after resmap.ResMap) []string {
+			return unchanged(before, after, what)
+		}
+		failed = func(_ resmap.ResMap, err error) []string {
+			return []string{fmt.Sprintf("%s: %v", what, err)}
+		}
+	case plugins.ReplicaCountTransformer:
+		// An entry may set the count a resource already
+		// has; only one that matches nothing is a problem,
+		// and the transformer fails on that.
+		name := kt.kustomization.Replicas[i].Name
+		what := fmt.Sprintf("replicas entry '%s'", name)
+		failed = func(before resmap.ResMap, err error) []string {
+			if !hasName(before, name) {
+				return []string{what + " matches no resource"}
+			}
+			return []string{fmt.Sprintf("%s: %v", what, err)}
+		}
+	case plugins.ImageTagTransformer:
+		fieldSpecs := ra.GetTransformerConfig().Images
+		check = func(before, _ resmap.ResMap) []string {
+			return kt.checkImage(i, fieldSpecs, before)
+		}
+	default:
+		return t
+	}
+	return &strictTransformer{
+		t:      t,
+		check:  check,
+		failed: failed,
+		note: func(problem string) {
+			kt.noteProblem(ra, problem)
+		},
+	}
+}
+
+// checkPatch checks the i'th entry of the patches field.
+func (kt *KustTarget) checkPatch(
+	i int, before, after resmap.ResMap) []string {
+	pc := kt.kustomization.Patches[i]
+	what := describeEntry("patches", i, pc.Path)
+	if pc.Target != nil {
+		resources, err := before.Select(*pc.Target)
+		if err == nil && len(resources) == 0 {
+			return []string{what + " matches no resource"}
+		}
+	}
+	return unchanged(before, after, what)
+}
+
+// checkPatchesStrategicMerge checks each entry of the
+// patchesStrategicMerge field.  The entries are applied
+// together, so each is applied alone, to a copy of the
+// resources, to find those that change nothing.
+func (kt *KustTarget) checkPatchesStrategicMerge(
+	before, _ resmap.ResMap) (result []string) {
+	bpt := plugins.PatchStrategicMergeTransformer
+	var c struct {
+		Paths []types.PatchStrategicMerge `json:"paths,omitempty" yaml:"paths,omitempty"`
+	}
+	for i, path := range kt.kustomization.PatchesStrategicMerge {
+		what := describeEntry("patchesStrategicMerge", i, string(path))
+		c.Paths = []types.PatchStrategicMerge{path}
+		p := plugins.TransformerFactories[bpt]()
+		err := kt.configureBuiltinPlugin(p, c, bpt)
+		if err != nil {
+			result = append(result, fmt.Sprintf("%s: %v", what, err))
+			continue
+		}
+		m := before.DeepCopy()
+		err = p.Transform(m)
+		if err != nil {
+			result = append(result, fmt.Sprintf("%s: %v", what, err))
+			continue
+		}
+		result = append(result, unchanged(before, m, what)...)
+	}
+	return result
+}
+
+// checkImage checks the i'th entry of the images field.
+// An entry may set the tag an image already has, so
+// rather than whether it changed anything, what's
+// checked is whether it matches any image: renaming
+// the images it matches must change something.
+func (kt *KustTarget) checkImage(
+	i int, fieldSpecs []types.FieldSpec, before resmap.ResMap) []string {
+	bpt := plugins.ImageTagTransformer
+	name := kt.kustomization.Images[i].Name
+	what := fmt.Sprintf("images entry '%s'", name)
+	var c struct {
+		ImageTag   types.Image
+		FieldSpecs []types.FieldSpec
+	}
+	c.ImageTag = types.Image{Name: name, NewName: name + "-unmatched"}
+	c.FieldSpecs = fieldSpecs
+	p := plugins.TransformerFactories[bpt]()
+	err := kt.configureBuiltinPlugin(p, c, bpt)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", what, err)}
+	}
+	m := before.DeepCopy()
+	err = p.Transform(m)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", what, err)}
+	}
+	if before.ErrorIfNotEqualLists(m) == nil {
+		return []string{what + " matches no image"}
+	}
+	return nil
+}
+
+// hasName returns true if a resource has, or had,
+// the given name.
+func hasName(m resmap.ResMap, name string) bool {
+	for _, r := range m.Resources() {
+		if r.CurId().Name == name || r.OrgId().Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unchanged returns a problem if the resources
+// are the same after a transformation as before.
+func unchanged(before, after resmap.ResMap, what string) []string {
+	if before.ErrorIfNotEqualLists(after) == nil {
+		return []string{what + " changes nothing"}
+	}
+	return nil
+}
+
+// describeEntry names the i'th entry of a kustomization
+// field, by the path of the file it names, if any,
+// or else by its index.
+func describeEntry(field string, i int, path string) string {
+	if path != "" && !strings.Contains(path, "\n") {
+		return fmt.Sprintf("%s entry '%s'", field, path)
+	}
+	return fmt.Sprintf("%s entry %d", field, i)
+}
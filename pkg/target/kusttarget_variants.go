@@ -0,0 +1,84 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+// Variant holds the customized resources
+// of one of a kustomization's variants.
+type Variant struct {
+	Name   string
+	ResMap resmap.ResMap
+}
+
+// HasVariants is true if the kustomization lists variants.
+func (kt *KustTarget) HasVariants() bool {
+	return len(kt.kustomization.Variants) > 0
+}
+
+// MakeCustomizedVariants returns the customized resources
+// of each of the kustomization's variants, in the order
+// listed.  The kustomization's own resources are accumulated
+// once, and a copy of them is customized per variant, as an
+// overlay holding the variant's fields would customize them.
+func (kt *KustTarget) MakeCustomizedVariants() ([]Variant, error) {
+	if !kt.HasVariants() {
+		return nil, fmt.Errorf(
+			"kustomization in '%s' has no variants", kt.ldr.Root())
+	}
+	err := kt.checkVariantNames()
+	if err != nil {
+		return nil, err
+	}
+	ra, err := kt.AccumulateTarget()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Variant, 0, len(kt.kustomization.Variants))
+	for _, v := range kt.kustomization.Variants {
+		vra := ra.DeepCopy()
+		err = kt.overlayTarget(v.Kustomization()).accumulateTarget(vra)
+		if err != nil {
+			return nil, errors.Wrapf(err, "variant '%s'", v.Name)
+		}
+		err = kt.accumulateOverlay(vra)
+		if err != nil {
+			return nil, err
+		}
+		m, err := kt.finishResMap(vra, types.GarbageIgnore)
+		if err != nil {
+			return nil, errors.Wrapf(err, "variant '%s'", v.Name)
+		}
+		result = append(result, Variant{Name: v.Name, ResMap: m})
+	}
+	return result, nil
+}
+
+// checkVariantNames assures that each variant has a
+// unique name that can name a directory.
+func (kt *KustTarget) checkVariantNames() error {
+	seen := map[string]bool{}
+	for i, v := range kt.kustomization.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("variant %d has no name", i)
+		}
+		if v.Name == "." || v.Name == ".." ||
+			filepath.Base(v.Name) != v.Name {
+			return fmt.Errorf(
+				"variant name '%s' cannot name a directory", v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("variant name '%s' is not unique", v.Name)
+		}
+		seen[v.Name] = true
+	}
+	return nil
+}
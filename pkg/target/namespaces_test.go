@@ -470,6 +470,38 @@ spec:
     protocol: UDP
 `
 
+// TestNamespaceConflict checks that moving a resource into
+// the namespace of another with the same name is an error.
+func TestNamespaceConflict(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+namespace: prod
+resources:
+- configmaps.yaml
+`)
+	th.WriteF("/app/configmaps.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: dev
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(),
+		"namespace tranformation produces ID conflict") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
 // TestVariablesAmbiguous demonstrates how two variables pointing at two different resources
 // using the same name in different namespaces are treated as ambiguous if the namespace is
 // not specified
@@ -0,0 +1,134 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeOriginResources(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+resources:
+- service.yaml
+configMapGenerator:
+- name: config
+  literals:
+  - a=b
+`)
+	th.WriteF("/app/base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: myService
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: otherService
+`)
+	th.WriteF("/app/overlay/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myDeployment
+`)
+}
+
+func TestOriginAnnotations(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeOriginResources(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+- deployment.yaml
+buildMetadata:
+- originAnnotations
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: ../base/service.yaml
+  name: myService
+---
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 1
+      path: ../base/service.yaml
+  name: otherService
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      configuredBy: builtin/ConfigMapGenerator
+      configuredIn: ../base/kustomization.yaml
+  name: config-8cm7t55ctd
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: deployment.yaml
+  name: myDeployment
+`)
+}
+
+func TestOriginAnnotationsEnabledByCaller(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/base")
+	writeOriginResources(th)
+	kt := th.MakeKustTarget()
+	kt.EnableOriginAnnotations()
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: service.yaml
+  name: myService
+---
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 1
+      path: service.yaml
+  name: otherService
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      configuredBy: builtin/ConfigMapGenerator
+      configuredIn: kustomization.yaml
+  name: config-8cm7t55ctd
+`)
+}
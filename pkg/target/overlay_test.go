@@ -0,0 +1,128 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+	"sigs.k8s.io/kustomize/v3/api/types"
+)
+
+func writeOverlayBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+namePrefix: base-
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - LOG_LEVEL=info
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.6
+        envFrom:
+        - configMapRef:
+            name: settings
+`)
+}
+
+// The output of a kustomization with an overlay set must
+// be that of a real overlay holding the same fields.
+func TestSetOverlayMatchesRealOverlay(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeOverlayBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+namespace: ci
+namePrefix: pr-12-
+commonLabels:
+  env: ci
+commonAnnotations:
+  build: "1234"
+images:
+- name: nginx
+  newTag: "1.7"
+`)
+	expected, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	th = kusttest_test.NewKustTestHarness(t, "/app/base")
+	writeOverlayBase(th)
+	kt := th.MakeKustTarget()
+	kt.SetOverlay(&types.Kustomization{
+		Namespace:         "ci",
+		NamePrefix:        "pr-12-",
+		CommonLabels:      map[string]string{"env": "ci"},
+		CommonAnnotations: map[string]string{"build": "1234"},
+		Images:            []types.Image{{Name: "nginx", NewTag: "1.7"}},
+	})
+	actual, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = expected.ErrorIfNotEqualLists(actual); err != nil {
+		t.Fatalf("unexpected difference: %v", err)
+	}
+	th.AssertActualEqualsExpected(actual, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    build: "1234"
+  labels:
+    env: ci
+  name: pr-12-base-web
+  namespace: ci
+spec:
+  selector:
+    matchLabels:
+      app: web
+      env: ci
+  template:
+    metadata:
+      annotations:
+        build: "1234"
+      labels:
+        app: web
+        env: ci
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: pr-12-base-settings-d59m7td94t
+        image: nginx:1.7
+        name: web
+---
+apiVersion: v1
+data:
+  LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+  annotations:
+    build: "1234"
+  labels:
+    env: ci
+  name: pr-12-base-settings-d59m7td94t
+  namespace: ci
+`)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+	"sigs.k8s.io/kustomize/v3/api/profile"
+)
+
+func TestProfileRecordsStages(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+resources:
+- service.yaml
+configMapGenerator:
+- name: cfg
+  literals:
+  - a=b
+`)
+	th.WriteF("/app/base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+namePrefix: dev-
+`)
+	p := profile.New()
+	kt := th.MakeKustTarget()
+	kt.SetProfile(p)
+	_, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	var actual []string
+	for _, s := range p.Stages {
+		actual = append(actual, s.Stage+" in "+s.Root)
+	}
+	sort.Strings(actual)
+	expected := []string{
+		"FixBackReferences in /app/overlay",
+		"ResolveVars in /app/overlay",
+		"accumulate in /app/base",
+		"accumulate in /app/overlay",
+		"generator ConfigMapGenerator in /app/base",
+		"hash in /app/overlay",
+		"transformer AnnotationsTransformer in /app/base",
+		"transformer AnnotationsTransformer in /app/overlay",
+		"transformer LabelTransformer in /app/base",
+		"transformer LabelTransformer in /app/overlay",
+		"transformer NamespaceTransformer in /app/base",
+		"transformer NamespaceTransformer in /app/overlay",
+		"transformer PrefixSuffixTransformer in /app/base",
+		"transformer PrefixSuffixTransformer in /app/overlay",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected stages\n%v\nbut got\n%v", expected, actual)
+	}
+}
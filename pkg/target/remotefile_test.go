@@ -0,0 +1,146 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+const remoteRelease = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 1
+`
+
+const remotePatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+spec:
+  replicas: 3
+`
+
+// serveRemoteFiles serves the files over plain http, so
+// only urls pinned to their sha256 may refer to them.
+// The count of requests served is kept in requests.
+func serveRemoteFiles(requests *int) *httptest.Server {
+	files := map[string]string{
+		"/release.yaml": remoteRelease,
+		"/patch.yaml":   remotePatch,
+	}
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			*requests++
+			content, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(content))
+		}))
+}
+
+func sumOf(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+func TestRemoteFileResourcesAndPatches(t *testing.T) {
+	var requests int
+	server := serveRemoteFiles(&requests)
+	defer server.Close()
+	sum := sumOf(remoteRelease)
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- `+server.URL+`/release.yaml#sha256=`+sum+`
+patches:
+- path: `+server.URL+`/patch.yaml#sha256=`+sumOf(remotePatch)+`
+buildMetadata:
+- originAnnotations
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |-
+      index: 0
+      path: `+server.URL+`/release.yaml#sha256=`+sum+`
+  name: controller
+spec:
+  replicas: 3
+`)
+}
+
+func TestRemoteFileChecksumMismatch(t *testing.T) {
+	var requests int
+	server := serveRemoteFiles(&requests)
+	defer server.Close()
+	sum := sumOf(remotePatch)
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- `+server.URL+`/release.yaml#sha256=`+sum+`
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(), "pinned to "+sum) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemoteFileOverPlainHttpMustBePinned(t *testing.T) {
+	var requests int
+	server := serveRemoteFiles(&requests)
+	defer server.Close()
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- `+server.URL+`/release.yaml
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil || !strings.Contains(err.Error(), "must use https") {
+		t.Fatalf("expected error about https, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests, got %d", requests)
+	}
+}
+
+// Only resources and patches entries may be urls;
+// other fields name local files.
+func TestRemoteFileNotFetchedForGenerators(t *testing.T) {
+	var requests int
+	server := serveRemoteFiles(&requests)
+	defer server.Close()
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+configMapGenerator:
+- name: release
+  files:
+  - `+server.URL+`/release.yaml#sha256=`+sumOf(remoteRelease)+`
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests, got %d", requests)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeReplacementBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+namePrefix: base-
+resources:
+- deployment.yaml
+- service.yaml
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.6
+        env:
+        - name: SERVICE_HOST
+          value: unknown
+        ports:
+        - containerPort: 8080
+`)
+	th.WriteF("/app/base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+    targetPort: 0
+`)
+}
+
+func TestReplacements(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeReplacementBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+images:
+- name: nginx
+  newTag: "1.7"
+replacements:
+- source:
+    kind: Service
+  targets:
+  - select:
+      kind: Deployment
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[name=web].env[name=SERVICE_HOST].value
+- source:
+    kind: Deployment
+    fieldPath: spec.template.spec.containers[0].ports[0].containerPort
+  targets:
+  - select:
+      kind: Service
+    fieldPaths:
+    - spec.ports[port=80].targetPort
+- source:
+    kind: Deployment
+    fieldPath: spec.template.spec.containers[0].image
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers[0].env[0].value
+    options:
+      delimiter: '-'
+      index: 0
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The values copied are the ones found after the
+	// overlay's other transformers, e.g. the images
+	// field, have run.
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: base-web
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: SERVICE_HOST
+          value: nginx:1.7-web
+        image: nginx:1.7
+        name: web
+        ports:
+        - containerPort: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: base-web
+spec:
+  ports:
+  - port: 80
+    targetPort: 8080
+`)
+}
+
+func TestReplacementsInBase(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeReplacementBase(th)
+	th.WriteK("/app/base", `
+namePrefix: base-
+resources:
+- deployment.yaml
+- service.yaml
+replacements:
+- source:
+    kind: Service
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers[0].env[0].value
+`)
+	th.WriteK("/app/overlay", `
+namePrefix: prod-
+resources:
+- ../base
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Unlike a var, a replacement in a base copies the value
+	// the base sees; the overlay's prefix is not applied to it.
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-base-web
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: SERVICE_HOST
+          value: base-web
+        image: nginx:1.6
+        name: web
+        ports:
+        - containerPort: 8080
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-base-web
+spec:
+  ports:
+  - port: 80
+    targetPort: 0
+`)
+}
+
+func TestReplacementsSelectorMissesTarget(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeReplacementBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+replacements:
+- source:
+    kind: Service
+  targets:
+  - select:
+      kind: StatefulSet
+    fieldPaths:
+    - metadata.name
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "picks no resources") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
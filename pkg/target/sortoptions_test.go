@@ -0,0 +1,170 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeSortOptionsResources(th *kusttest_test.KustTestHarness, dir string) {
+	th.WriteF(dir+"/resources.yaml", `
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: guard
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: front
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: back
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+`)
+}
+
+func TestSortOptions(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+namespace: shop
+resources:
+- resources.yaml
+sortOptions:
+  kindPriority:
+  - Deployment
+  - ValidatingWebhookConfiguration
+`)
+	writeSortOptionsResources(th, "/app")
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: back
+  namespace: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: front
+  namespace: shop
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: guard
+`)
+}
+
+func TestSortOptionsIgnoredInBase(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+resources:
+- resources.yaml
+sortOptions:
+  kindPriority:
+  - Deployment
+`)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+`)
+	writeSortOptionsResources(th, "/app/base")
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: guard
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: front
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: back
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+`)
+}
+
+func TestSortOrderTransformerFromTransformersField(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "SortOrderTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	th.WriteK("/app", `
+resources:
+- resources.yaml
+transformers:
+- sorter.yaml
+`)
+	th.WriteF("/app/sorter.yaml", `
+apiVersion: builtin
+kind: SortOrderTransformer
+metadata:
+  name: notImportantHere
+kindPriority:
+- Deployment
+`)
+	writeSortOptionsResources(th, "/app")
+	kt := th.MakeKustTarget()
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if !kt.SortsOutput() {
+		t.Fatalf("expected the target to sort its output")
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: back
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: front
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: guard
+`)
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeStrictBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+resources:
+- deployment.yaml
+images:
+- name: nginx
+  newTag: "1.7"
+- name: redis
+  newTag: "5"
+- name: busybox
+  newTag: "1.0"
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.6
+      - name: sidecar
+        image: busybox:1.0
+`)
+}
+
+func writeStrictOverlay(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+patchesStrategicMerge:
+- replicas.yaml
+- same.yaml
+patches:
+- target:
+    kind: StatefulSet
+  patch: |-
+    - op: replace
+      path: /spec/replicas
+      value: 5
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: web
+  path: noop.yaml
+vars:
+- name: WEB
+  objref:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: web
+`)
+	th.WriteF("/app/overlay/replicas.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`)
+	th.WriteF("/app/overlay/same.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.7
+`)
+	th.WriteF("/app/overlay/noop.yaml", `
+- op: test
+  path: /metadata/name
+  value: web
+`)
+}
+
+func TestStrictBuild(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeStrictBase(th)
+	writeStrictOverlay(th)
+
+	// Without strict, the entries that do nothing are ignored.
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: nginx:1.7
+        name: web
+      - image: busybox:1.0
+        name: sidecar
+`)
+
+	kt := th.MakeKustTarget()
+	kt.SetStrict(true)
+	_, err = kt.MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	expected := `strict build found 5 problem(s):
+  /app/base/kustomization.yaml: images entry 'redis' matches no image
+  /app/overlay/kustomization.yaml: patchesStrategicMerge entry 'same.yaml' changes nothing
+  /app/overlay/kustomization.yaml: patches entry 0 matches no resource
+  /app/overlay/kustomization.yaml: patchesJson6902 entry 'noop.yaml' changes nothing
+  /app/overlay/kustomization.yaml: vars never used: WEB`
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\ngot\n%v", expected, err)
+	}
+}
+
+func TestStrictBuildListsMissingTargets(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeStrictBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+patchesStrategicMerge:
+- replicas.yaml
+- missing.yaml
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: missing
+  path: scale.yaml
+replicas:
+- name: missing
+  count: 2
+- name: web
+  count: 3
+`)
+	th.WriteF("/app/overlay/replicas.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+`)
+	th.WriteF("/app/overlay/missing.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: missing
+spec:
+  replicas: 3
+`)
+	th.WriteF("/app/overlay/scale.yaml", `
+- op: replace
+  path: /spec/replicas
+  value: 5
+`)
+
+	kt := th.MakeKustTarget()
+	kt.SetStrict(true)
+	kt.SetVarValues(map[string]string{"SHARED": "from a var file"})
+	_, err := kt.MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	missing := "no matches for OriginalId apps_v1_Deployment|~X|missing; " +
+		"no matches for CurrentId apps_v1_Deployment|~X|missing; " +
+		"failed to find unique target for patch apps_v1_Deployment|missing"
+	expected := `strict build found 4 problem(s):
+  /app/base/kustomization.yaml: images entry 'redis' matches no image
+  /app/overlay/kustomization.yaml: patchesStrategicMerge entry 'missing.yaml': ` +
+		missing + `
+  /app/overlay/kustomization.yaml: patchesJson6902 entry 'scale.yaml': ` +
+		missing + `
+  /app/overlay/kustomization.yaml: replicas entry 'missing' matches no resource`
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\ngot\n%v", expected, err)
+	}
+}
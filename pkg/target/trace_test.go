@@ -0,0 +1,81 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+	"sigs.k8s.io/kustomize/v3/api/trace"
+)
+
+func TestTraceRecordsTransformations(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: cfg
+  literals:
+  - a=b
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        envFrom:
+        - configMapRef:
+            name: cfg
+`)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+namePrefix: dev-
+`)
+	tr := trace.New()
+	kt := th.MakeKustTarget()
+	kt.SetTrace(tr)
+	_, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	expected := `
+step 1: NamespaceTransformer in /app/base
+  no changes
+step 2: PrefixSuffixTransformer in /app/base
+  no changes
+step 3: LabelTransformer in /app/base
+  no changes
+step 4: AnnotationsTransformer in /app/base
+  no changes
+step 5: NamespaceTransformer in /app/overlay
+  no changes
+step 6: PrefixSuffixTransformer in /app/overlay
+  modified apps_v1_Deployment|~X|dev-web
+    metadata/name: "web" -> "dev-web"
+  modified ~G_v1_ConfigMap|~X|dev-cfg
+    metadata/name: "cfg" -> "dev-cfg"
+step 7: LabelTransformer in /app/overlay
+  no changes
+step 8: AnnotationsTransformer in /app/overlay
+  no changes
+step 9: HashTransformer in /app/overlay
+  modified ~G_v1_ConfigMap|~X|dev-cfg-hm2888kfk9
+    metadata/name: "dev-cfg" -> "dev-cfg-hm2888kfk9"
+step 10: NameReferenceTransformer in /app/overlay
+  modified apps_v1_Deployment|~X|dev-web
+    spec/template/spec/containers[0]/envFrom[0]/configMapRef/name: "cfg" -> "dev-cfg-hm2888kfk9"
+`
+	if string(tr.Report()) != strings.TrimPrefix(expected, "\n") {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, tr.Report())
+	}
+}
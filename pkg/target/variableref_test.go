@@ -1354,3 +1354,63 @@ spec:
     protocol: TCP
 `)
 }
+
+func TestVarValuesFromOutsideTheBuild(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- pod.yaml
+vars:
+- name: POD_NAME
+  objref:
+    apiVersion: v1
+    kind: Pod
+    name: clown
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    command:
+    - echo
+    - "$(POD_NAME) $(GIT_SHA)"
+    - "$(UNDEFINED)"
+`)
+	kt := th.MakeKustTarget()
+	kt.SetVarValues(map[string]string{"GIT_SHA": "0a1b2c"})
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - command:
+    - echo
+    - clown 0a1b2c
+    - $(UNDEFINED)
+    image: frown
+    name: frown
+`)
+
+	kt = th.MakeKustTarget()
+	kt.SetVarValues(map[string]string{"GIT_SHA": "0a1b2c"})
+	kt.SetStrict(true)
+	_, err = kt.MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(),
+		"references to undefined vars: UNDEFINED (in ~G_v1_Pod|~X|clown)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+func writeVariantApp(th *kusttest_test.KustTestHarness, variants string) {
+	th.WriteK("/app", `
+namePrefix: app-
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - LOG_LEVEL=info
+generatorOptions:
+  disableNameSuffixHash: true
+`+variants)
+	th.WriteF("/app/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.6
+        envFrom:
+        - configMapRef:
+            name: settings
+`)
+}
+
+func TestVariants(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	writeVariantApp(th, `
+variants:
+- name: east
+  namespace: east
+  images:
+  - name: nginx
+    newTag: "1.7"
+  configMapGenerator:
+  - name: settings
+    behavior: merge
+    literals:
+    - REGION=east
+- name: west
+  namespace: west
+  replicas:
+  - name: web
+    count: 3
+`)
+	kt := th.MakeKustTarget()
+
+	// The variants don't change the kustomization's own output.
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Size() != 2 {
+		t.Fatalf("expected 2 resources, got %d", m.Size())
+	}
+
+	vs, err := kt.MakeCustomizedVariants()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vs) != 2 || vs[0].Name != "east" || vs[1].Name != "west" {
+		t.Fatalf("unexpected variants %v", vs)
+	}
+	th.AssertActualEqualsExpected(vs[0].ResMap, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+  namespace: east
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: app-settings
+        image: nginx:1.7
+        name: web
+---
+apiVersion: v1
+data:
+  LOG_LEVEL: info
+  REGION: east
+kind: ConfigMap
+metadata:
+  annotations: {}
+  labels: {}
+  name: app-settings
+  namespace: east
+`)
+	th.AssertActualEqualsExpected(vs[1].ResMap, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+  namespace: west
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - envFrom:
+        - configMapRef:
+            name: app-settings
+        image: nginx:1.6
+        name: web
+---
+apiVersion: v1
+data:
+  LOG_LEVEL: info
+kind: ConfigMap
+metadata:
+  name: app-settings
+  namespace: west
+`)
+}
+
+func TestVariantsErrors(t *testing.T) {
+	testCases := map[string]struct {
+		variants string
+		expected string
+	}{
+		"none": {
+			variants: ``,
+			expected: "has no variants",
+		},
+		"noName": {
+			variants: `
+variants:
+- namespace: east
+`,
+			expected: "variant 0 has no name",
+		},
+		"badName": {
+			variants: `
+variants:
+- name: prod/east
+`,
+			expected: "variant name 'prod/east' cannot name a directory",
+		},
+		"duplicateName": {
+			variants: `
+variants:
+- name: east
+- name: east
+`,
+			expected: "variant name 'east' is not unique",
+		},
+		"badMerge": {
+			variants: `
+variants:
+- name: east
+  configMapGenerator:
+  - name: other
+    behavior: merge
+    literals:
+    - REGION=east
+`,
+			expected: "variant 'east'",
+		},
+	}
+	for n, tc := range testCases {
+		th := kusttest_test.NewKustTestHarness(t, "/app")
+		writeVariantApp(th, tc.variants)
+		_, err := th.MakeKustTarget().MakeCustomizedVariants()
+		if err == nil {
+			t.Fatalf("%s: expected an error", n)
+		}
+		if !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("%s: unexpected error: %v", n, err)
+		}
+	}
+}
@@ -29,11 +29,13 @@ func (p *NamespaceTransformerPlugin) Transform(m resmap.ResMap) error {
 	if len(p.Namespace) == 0 {
 		return nil
 	}
+	var changed []*resource.Resource
 	for _, r := range m.Resources() {
 		if len(r.Map()) == 0 {
 			// Don't mutate empty objects?
 			continue
 		}
+		changed = append(changed, r)
 
 		id := r.OrgId()
 		applicableFs := p.applicableFieldSpecs(id)
@@ -46,8 +48,11 @@ func (p *NamespaceTransformerPlugin) Transform(m resmap.ResMap) error {
 				return err
 			}
 		}
-
-		matches := m.GetMatchingResourcesByCurrentId(r.CurId().Equals)
+	}
+	// Check the ids once all have changed, so that the
+	// lookups needn't follow each change as it's made.
+	for _, r := range changed {
+		matches := m.GetAllByCurrentId(r.CurId())
 		if len(matches) != 1 {
 			return fmt.Errorf("namespace tranformation produces ID conflict: %#v", matches)
 		}
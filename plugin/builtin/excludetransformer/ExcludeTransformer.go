@@ -0,0 +1,84 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Remove the resources picked by any of a list of
+// selectors.  Every selector must pick at least one
+// resource, so that a typo doesn't silently keep
+// a resource that was meant to go.
+type plugin struct {
+	Exclude []types.Selector `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Exclude = nil
+	err = yaml.Unmarshal(c, p)
+	if err != nil {
+		return err
+	}
+	// Check the patterns now; selecting
+	// with a bad one would panic.
+	for _, s := range p.Exclude {
+		for _, pattern := range []string{s.Name, s.Namespace} {
+			if _, err = regexp.Compile(pattern); err != nil {
+				return fmt.Errorf(
+					"exclude selector %s: %v", p.describe(s), err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	var doomed []*resource.Resource
+	seen := map[*resource.Resource]bool{}
+	for _, s := range p.Exclude {
+		matches, err := m.Select(s)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf(
+				"exclude selector %s picks no resources", p.describe(s))
+		}
+		for _, r := range matches {
+			if !seen[r] {
+				seen[r] = true
+				doomed = append(doomed, r)
+			}
+		}
+	}
+	for _, r := range doomed {
+		err := m.Remove(r.CurId())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *plugin) describe(s types.Selector) string {
+	y, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%v", s)
+	}
+	return "{" + strings.Replace(
+		strings.TrimSpace(string(y)), "\n", ", ", -1) + "}"
+}
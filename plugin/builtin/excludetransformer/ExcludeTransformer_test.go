@@ -0,0 +1,128 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+const excludeInput = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  labels:
+    app: web
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: smoke-test
+  namespace: test
+  annotations:
+    purpose: test
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`
+
+func TestExcludeTransformer(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ExcludeTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ExcludeTransformer
+metadata:
+  name: notImportantHere
+exclude:
+- group: policy
+  kind: PodDisruptionBudget
+  labelSelector: app=web
+- kind: Job
+  name: smoke-.*
+- annotationSelector: purpose=test
+`, excludeInput)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: web
+  name: web
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`)
+}
+
+func TestExcludeTransformerPicksNothing(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ExcludeTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ExcludeTransformer
+metadata:
+  name: notImportantHere
+exclude:
+- kind: Job
+- kind: PodDisruptionBudge
+`, excludeInput)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(),
+		"exclude selector {kind: PodDisruptionBudge} picks no resources") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
+
+func TestExcludeTransformerBadPattern(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ExcludeTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ExcludeTransformer
+metadata:
+  name: notImportantHere
+exclude:
+- name: "["
+`, excludeInput)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.Contains(err.Error(),
+		"exclude selector {name: '['}: error parsing regexp") {
+		t.Fatalf("unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/resource"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Copy the values of fields into other fields, in the
+// order the replacements are listed.  A source must pick
+// exactly one resource, each target must pick at least
+// one, and every field named must exist; anything else
+// is an error.
+type plugin struct {
+	Replacements []types.Replacement `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.Replacements = nil
+	return yaml.Unmarshal(c, p)
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	for i, r := range p.Replacements {
+		err := p.replace(m, r)
+		if err != nil {
+			return fmt.Errorf("replacement %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (p *plugin) replace(m resmap.ResMap, r types.Replacement) error {
+	if r.Source == nil {
+		return fmt.Errorf("missing source")
+	}
+	if len(r.Targets) == 0 {
+		return fmt.Errorf("missing targets")
+	}
+	value, err := p.sourceValue(m, r.Source)
+	if err != nil {
+		return err
+	}
+	for _, t := range r.Targets {
+		err = p.replaceTarget(m, t, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *plugin) sourceValue(
+	m resmap.ResMap, s *types.ReplacementSource) (interface{}, error) {
+	matches, err := m.Select(s.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) != 1 {
+		return nil, fmt.Errorf(
+			"source selector %s picks %d resources; expecting exactly 1%s",
+			p.describe(s.Selector), len(matches), p.ids(matches))
+	}
+	path := s.FieldPath
+	if path == "" {
+		path = types.DefaultReplacementFieldPath
+	}
+	var value interface{}
+	err = p.visit(matches[0], path, func(v interface{}) (interface{}, error) {
+		value = v
+		return v, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *plugin) replaceTarget(
+	m resmap.ResMap, t *types.ReplacementTarget, value interface{}) error {
+	if t.Select == nil {
+		return fmt.Errorf("target has no select")
+	}
+	if len(t.FieldPaths) == 0 {
+		return fmt.Errorf(
+			"target %s has no fieldPaths", p.describe(*t.Select))
+	}
+	matches, err := m.Select(*t.Select)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf(
+			"target selector %s picks no resources", p.describe(*t.Select))
+	}
+	for _, res := range matches {
+		for _, path := range t.FieldPaths {
+			err = p.visit(res, path, func(old interface{}) (interface{}, error) {
+				return p.newValue(old, value, t.Options)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newValue returns the value to put in place of old.
+func (p *plugin) newValue(
+	old, value interface{}, opts *types.ReplacementOptions) (interface{}, error) {
+	if opts == nil {
+		return copyValue(value), nil
+	}
+	if opts.Delimiter == "" {
+		return nil, fmt.Errorf("options need a delimiter")
+	}
+	s, ok := old.(string)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%v is a %T; a delimiter needs a string", old, old)
+	}
+	switch value.(type) {
+	case string, int64, float64, bool:
+	default:
+		return nil, fmt.Errorf(
+			"%v is a %T; only a string, number or bool "+
+				"can replace part of a field", value, value)
+	}
+	parts := strings.Split(s, opts.Delimiter)
+	if opts.Index < 0 || opts.Index >= len(parts) {
+		return nil, fmt.Errorf(
+			"index %d is out of range for '%s' split by '%s'",
+			opts.Index, s, opts.Delimiter)
+	}
+	parts[opts.Index] = fmt.Sprint(value)
+	return strings.Join(parts, opts.Delimiter), nil
+}
+
+// visit finds the field at the path in the resource
+// and sets it to the value the function returns.
+func (p *plugin) visit(
+	res *resource.Resource, path string,
+	f func(interface{}) (interface{}, error)) error {
+	steps, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+	obj, err := visitPath(res.Map(), steps, f)
+	if err != nil {
+		return fmt.Errorf(
+			"field '%s' of %s: %v", path, res.CurId(), err)
+	}
+	res.SetMap(obj.(map[string]interface{}))
+	return nil
+}
+
+func (p *plugin) describe(s types.Selector) string {
+	y, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%v", s)
+	}
+	return "{" + strings.Replace(
+		strings.TrimSpace(string(y)), "\n", ", ", -1) + "}"
+}
+
+func (p *plugin) ids(matches []*resource.Resource) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	ids := make([]string, len(matches))
+	for i, r := range matches {
+		ids[i] = r.CurId().String()
+	}
+	return ": " + strings.Join(ids, ", ")
+}
+
+// A step in a field path.  It selects a field of a map,
+// or an element of a list, by index or by the value of
+// one of its fields.
+type pathStep struct {
+	field string
+	index int
+	key   string
+	value string
+}
+
+// parseFieldPath parses paths such as
+// spec.template.spec.containers[name=web].ports[0].containerPort
+func parseFieldPath(path string) ([]pathStep, error) {
+	var result []pathStep
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '.':
+			if i == 0 || i == len(path)-1 || path[i-1] == '.' {
+				return nil, fmt.Errorf("invalid field path '%s'", path)
+			}
+			i++
+		case path[i] == '[':
+			j := strings.IndexByte(path[i:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("unclosed '[' in field path '%s'", path)
+			}
+			s, err := parseListPathStep(path[i+1 : i+j])
+			if err != nil {
+				return nil, fmt.Errorf("%v in field path '%s'", err, path)
+			}
+			result = append(result, s)
+			i += j + 1
+			if i < len(path) && path[i] != '.' && path[i] != '[' {
+				return nil, fmt.Errorf("invalid field path '%s'", path)
+			}
+		default:
+			j := strings.IndexAny(path[i:], ".[")
+			if j < 0 {
+				j = len(path) - i
+			}
+			result = append(result, pathStep{field: path[i : i+j], index: -1})
+			i += j
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty field path")
+	}
+	return result, nil
+}
+
+func parseListPathStep(s string) (pathStep, error) {
+	if kv := strings.SplitN(s, "=", 2); len(kv) == 2 {
+		if kv[0] == "" {
+			return pathStep{}, fmt.Errorf("missing key in '[%s]'", s)
+		}
+		return pathStep{index: -1, key: kv[0], value: kv[1]}, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return pathStep{}, fmt.Errorf(
+			"'[%s]' is neither an index nor a key=value", s)
+	}
+	return pathStep{index: i}, nil
+}
+
+// visitPath walks the steps from the node and returns
+// the node with the field at the end of the steps set
+// to the value the function returns.
+func visitPath(
+	node interface{}, steps []pathStep,
+	f func(interface{}) (interface{}, error)) (interface{}, error) {
+	if len(steps) == 0 {
+		return f(node)
+	}
+	s := steps[0]
+	if s.field != "" {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("can't find '%s' in a %T", s.field, node)
+		}
+		child, found := m[s.field]
+		if !found {
+			return nil, fmt.Errorf("no field named '%s'", s.field)
+		}
+		child, err := visitPath(child, steps[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		m[s.field] = child
+		return m, nil
+	}
+	l, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("can't index a %T", node)
+	}
+	i := s.index
+	if s.key != "" {
+		var err error
+		i, err = findListElement(l, s.key, s.value)
+		if err != nil {
+			return nil, err
+		}
+	} else if i >= len(l) {
+		return nil, fmt.Errorf("index %d is out of range", i)
+	}
+	child, err := visitPath(l[i], steps[1:], f)
+	if err != nil {
+		return nil, err
+	}
+	l[i] = child
+	return l, nil
+}
+
+// findListElement returns the index of the one map in the
+// list whose field key has the given value.
+func findListElement(l []interface{}, key, value string) (int, error) {
+	result := -1
+	for i, e := range l {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v, found := m[key]
+		if !found || fmt.Sprint(v) != value {
+			continue
+		}
+		if result >= 0 {
+			return -1, fmt.Errorf(
+				"more than one element has %s=%s", key, value)
+		}
+		result = i
+	}
+	if result < 0 {
+		return -1, fmt.Errorf("no element has %s=%s", key, value)
+	}
+	return result, nil
+}
+
+// copyValue copies the maps and lists in the value, so
+// that no two targets share them.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, x := range v {
+			result[k] = copyValue(x)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, x := range v {
+			result[i] = copyValue(x)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+const replacementInput = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  tag: "1.7"
+  port: "8080"
+  replicas: 3
+  resources:
+    limits:
+      cpu: 500m
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: proxy:1.0
+      - name: web
+        image: nginx:1.6
+        resources: {}
+        ports:
+        - containerPort: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 1
+`
+
+func TestReplacementTransformer(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ReplacementTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplacementTransformer
+metadata:
+  name: notImportantHere
+replacements:
+- source:
+    kind: ConfigMap
+    name: settings
+    fieldPath: data.tag
+  targets:
+  - select:
+      kind: Deployment
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[name=web].image
+    options:
+      delimiter: ':'
+      index: 1
+- source:
+    kind: ConfigMap
+    fieldPath: data.replicas
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.replicas
+- source:
+    kind: ConfigMap
+    fieldPath: data.resources
+  targets:
+  - select:
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[1].resources
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: worker
+    fieldPaths:
+    - metadata.name
+    options:
+      delimiter: '-'
+`, replacementInput)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+data:
+  port: "8080"
+  replicas: 3
+  resources:
+    limits:
+      cpu: 500m
+  tag: "1.7"
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: proxy:1.0
+        name: sidecar
+      - image: nginx:1.7
+        name: web
+        ports:
+        - containerPort: 80
+        resources:
+          limits:
+            cpu: 500m
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: settings
+spec:
+  replicas: 3
+`)
+}
+
+func TestReplacementTransformerErrors(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ReplacementTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	testCases := map[string]struct {
+		replacements string
+		expected     string
+	}{
+		"noSource": {
+			replacements: `
+- targets:
+  - select:
+      name: web
+    fieldPaths: [spec.replicas]
+`,
+			expected: "replacement 0: missing source",
+		},
+		"noTargets": {
+			replacements: `
+- source:
+    kind: ConfigMap
+`,
+			expected: "replacement 0: missing targets",
+		},
+		"ambiguousSource": {
+			replacements: `
+- source:
+    kind: Deployment
+  targets:
+  - select:
+      name: web
+    fieldPaths: [spec.replicas]
+`,
+			expected: "picks 2 resources; expecting exactly 1",
+		},
+		"missingSource": {
+			replacements: `
+- source:
+    kind: Secret
+  targets:
+  - select:
+      name: web
+    fieldPaths: [spec.replicas]
+`,
+			expected: "picks 0 resources; expecting exactly 1",
+		},
+		"missingSourceField": {
+			replacements: `
+- source:
+    kind: ConfigMap
+    fieldPath: data.missing
+  targets:
+  - select:
+      name: web
+    fieldPaths: [spec.replicas]
+`,
+			expected: "no field named 'missing'",
+		},
+		"noSelect": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - fieldPaths: [spec.replicas]
+`,
+			expected: "target has no select",
+		},
+		"noFieldPaths": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+`,
+			expected: "has no fieldPaths",
+		},
+		"missingTarget": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      kind: Service
+    fieldPaths: [spec.replicas]
+`,
+			expected: "target selector {kind: Service} picks no resources",
+		},
+		"missingTargetField": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: worker
+    fieldPaths: [spec.template.spec]
+`,
+			expected: "field 'spec.template.spec' of apps_v1_Deployment|~X|worker: " +
+				"no field named 'template'",
+		},
+		"missingElement": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: ['spec.template.spec.containers[name=db].image']
+`,
+			expected: "no element has name=db",
+		},
+		"indexOutOfRange": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: ['spec.template.spec.containers[2].image']
+`,
+			expected: "index 2 is out of range",
+		},
+		"badPath": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: [spec..replicas]
+`,
+			expected: "invalid field path 'spec..replicas'",
+		},
+		"badListStep": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: ['spec.template.spec.containers[web].image']
+`,
+			expected: "'[web]' is neither an index nor a key=value",
+		},
+		"noDelimiter": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: [metadata.name]
+    options:
+      index: 1
+`,
+			expected: "options need a delimiter",
+		},
+		"delimiterInNonString": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: [spec.replicas]
+    options:
+      delimiter: '-'
+`,
+			expected: "a delimiter needs a string",
+		},
+		"structuredPartOfField": {
+			replacements: `
+- source:
+    kind: ConfigMap
+    fieldPath: data.resources
+  targets:
+  - select:
+      name: web
+    fieldPaths: [metadata.name]
+    options:
+      delimiter: '-'
+`,
+			expected: "can replace part of a field",
+		},
+		"delimiterIndexOutOfRange": {
+			replacements: `
+- source:
+    kind: ConfigMap
+  targets:
+  - select:
+      name: web
+    fieldPaths: [metadata.name]
+    options:
+      delimiter: '-'
+      index: 1
+`,
+			expected: "index 1 is out of range for 'web' split by '-'",
+		},
+	}
+	for n, tc := range testCases {
+		err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplacementTransformer
+metadata:
+  name: notImportantHere
+replacements:`+tc.replacements, replacementInput)
+		if err == nil {
+			t.Fatalf("%s: expected error", n)
+		}
+		if !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("%s: unexpected err: %v", n, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate pluginator
+package main
+
+import (
+	"sort"
+
+	"sigs.k8s.io/kustomize/v3/api/resid"
+	"sigs.k8s.io/kustomize/v3/api/resmap"
+	"sigs.k8s.io/kustomize/v3/api/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Sort the resources into an order suitable for applying
+// them to a cluster.  Resources are sorted in phases:
+//
+//   - Namespaces,
+//   - CustomResourceDefinitions,
+//   - everything else, ordered by the user's kind priority
+//     list, then by the legacy order defined in the Gvk class,
+//   - admission webhook configurations.
+//
+// The first two phases assure that no resource precedes the
+// namespace it lives in or the CRD that defines its kind,
+// and the last assures that no webhook can intercept the
+// creation of any other resource in the build.
+type plugin struct {
+	types.SortOptions `json:",inline,omitempty" yaml:",inline,omitempty"`
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	h *resmap.PluginHelpers, c []byte) (err error) {
+	p.KindPriority = nil
+	return yaml.Unmarshal(c, p)
+}
+
+const (
+	sortPhaseNamespace = iota
+	sortPhaseCrd
+	sortPhaseOther
+	sortPhaseWebhook
+)
+
+func sortPhase(gvk resid.Gvk) int {
+	switch gvk.Kind {
+	case "Namespace":
+		return sortPhaseNamespace
+	case "CustomResourceDefinition":
+		return sortPhaseCrd
+	case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+		return sortPhaseWebhook
+	default:
+		return sortPhaseOther
+	}
+}
+
+func (p *plugin) Transform(m resmap.ResMap) (err error) {
+	priority := make(map[string]int, len(p.KindPriority))
+	for i, k := range p.KindPriority {
+		if _, ok := priority[k]; !ok {
+			priority[k] = i
+		}
+	}
+	rank := func(id resid.ResId) (int, int) {
+		r, ok := priority[id.Kind]
+		if !ok {
+			r = len(p.KindPriority)
+		}
+		return sortPhase(id.Gvk), r
+	}
+	resources := m.Resources()
+	sort.SliceStable(resources, func(i, j int) bool {
+		idI := resources[i].CurId()
+		idJ := resources[j].CurId()
+		phaseI, rankI := rank(idI)
+		phaseJ, rankJ := rank(idJ)
+		if phaseI != phaseJ {
+			return phaseI < phaseJ
+		}
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return resmap.IdSlice{idI, idJ}.Less(0, 1)
+	})
+	m.Clear()
+	for _, r := range resources {
+		if err = m.Append(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
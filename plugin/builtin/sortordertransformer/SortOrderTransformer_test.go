@@ -0,0 +1,163 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kustomize/v3/api/kusttest"
+)
+
+const sortOrderInput = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: papaya
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: plum
+---
+apiVersion: example.com/v1
+kind: Fruit
+metadata:
+  name: banana
+  namespace: orchard
+---
+apiVersion: v1
+kind: Deployment
+metadata:
+  name: pear
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: fruits.example.com
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: orchard
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apricot
+`
+
+func TestSortOrderTransformerDefault(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "SortOrderTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: SortOrderTransformer
+metadata:
+  name: notImportantHere
+`, sortOrderInput)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: orchard
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: fruits.example.com
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apricot
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: papaya
+---
+apiVersion: v1
+kind: Deployment
+metadata:
+  name: pear
+---
+apiVersion: example.com/v1
+kind: Fruit
+metadata:
+  name: banana
+  namespace: orchard
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: plum
+`)
+}
+
+func TestSortOrderTransformerKindPriority(t *testing.T) {
+	tc := kusttest_test.NewPluginTestEnv(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "SortOrderTransformer")
+
+	th := kusttest_test.NewKustTestHarnessAllowPlugins(t, "/app")
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: SortOrderTransformer
+metadata:
+  name: notImportantHere
+kindPriority:
+- MutatingWebhookConfiguration
+- Fruit
+- Deployment
+- Namespace
+`, sortOrderInput)
+
+	// Namespaces, CRDs and webhooks keep their
+	// positions despite the priority list.
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: orchard
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: fruits.example.com
+---
+apiVersion: example.com/v1
+kind: Fruit
+metadata:
+  name: banana
+  namespace: orchard
+---
+apiVersion: v1
+kind: Deployment
+metadata:
+  name: pear
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: apricot
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: papaya
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: plum
+`)
+}